@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type RejectReason string
+
+const (
+	ReasonMalformed           RejectReason = "malformed_token"
+	ReasonUnknownKey          RejectReason = "unknown_key"
+	ReasonAlgorithmNotAllowed RejectReason = "algorithm_not_allowed"
+	ReasonInvalidSignature    RejectReason = "invalid_signature"
+	ReasonExpired             RejectReason = "token_expired"
+	ReasonNotYetValid         RejectReason = "token_not_yet_valid"
+	ReasonIssuedInFuture      RejectReason = "token_issued_in_future"
+	ReasonIssuerMismatch      RejectReason = "issuer_mismatch"
+	ReasonAudienceMismatch    RejectReason = "audience_mismatch"
+	ReasonUnauthorizedParty   RejectReason = "unauthorized_party"
+	ReasonMissingClaim        RejectReason = "missing_claim"
+	ReasonInvalidClaims       RejectReason = "invalid_claims"
+)
+
+// TokenError tells why a token was rejected, Err keeps the underlying cause for logs.
+type TokenError struct {
+	Reason RejectReason
+	Err    error
+}
+
+func (e *TokenError) Error() string {
+	if e.Err == nil {
+		return string(e.Reason)
+	}
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+func reject(reason RejectReason, err error) *TokenError {
+	return &TokenError{Reason: reason, Err: err}
+}
+
+// classify maps golang-jwt errors to reject reasons, keeping reasons already set by the key func.
+func classify(err error) *TokenError {
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		return tokenErr
+	}
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return reject(ReasonMalformed, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return reject(ReasonInvalidSignature, err)
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		return reject(ReasonUnknownKey, err)
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return reject(ReasonMissingClaim, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return reject(ReasonExpired, err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return reject(ReasonNotYetValid, err)
+	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return reject(ReasonIssuedInFuture, err)
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return reject(ReasonIssuerMismatch, err)
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return reject(ReasonAudienceMismatch, err)
+	default:
+		return reject(ReasonInvalidClaims, err)
+	}
+}
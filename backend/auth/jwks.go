@@ -2,24 +2,51 @@ package auth
 
 import (
 	"context"
-	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/lestrrat-go/httprc/v3"
 	"github.com/lestrrat-go/jwx/v3/jwk"
 	gologger "github.com/nrf24l01/go-logger"
 	"github.com/silaeder-labs/bank/backend/config"
+	"github.com/silaeder-labs/bank/backend/logging"
 )
 
-func RegisterJwks(cfg *config.KeyCloakConfig, logger *gologger.Logger, ctx *context.Context) (*jwk.Cache, error) {
+// RegisterProviders resolves key sources for every trusted issuer and builds the verifier.
+func RegisterProviders(cfg *config.OIDCConfig, logger *logging.Logger, ctx *context.Context) (*Verifier, error) {
 	c, err := jwk.NewCache(*ctx, httprc.NewClient())
 	if err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("AUTH"), fmt.Sprintf("failed to create cache: %s", err), "")
+		logger.LogFields(gologger.LevelFatal, logging.TypeAuth, "failed to create cache", logging.Fields{Error: err})
 		return nil, err
 	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
 
-	if err := c.Register(*ctx, cfg.URL); err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("AUTH"), fmt.Sprintf("failed to register google JWKS: %s", err), "")
-		return nil, err
+	var providers []*Provider
+	for _, p := range cfg.Providers {
+		var keys KeySource
+		source := p.JWKSFile
+		switch {
+		case p.JWKSFile != "":
+			keys, err = NewStaticKeySource(p.JWKSFile)
+		default:
+			source = p.JWKSURL
+			if source == "" {
+				source, err = DiscoverJWKSURL(*ctx, httpClient, p.Issuer)
+				if err != nil {
+					logger.LogFields(gologger.LevelFatal, logging.TypeAuth, "failed to discover JWKS", logging.Fields{Error: err, Extra: map[string]any{"issuer": p.Issuer}})
+					return nil, err
+				}
+			}
+			keys, err = NewRemoteKeySource(*ctx, c, source)
+		}
+		if err != nil {
+			logger.LogFields(gologger.LevelFatal, logging.TypeAuth, "failed to register JWKS", logging.Fields{Error: err, Extra: map[string]any{"issuer": p.Issuer, "jwks": source}})
+			return nil, err
+		}
+
+		logger.LogFields(gologger.LevelInfo, logging.TypeAuth, "trusted issuer registered", logging.Fields{Extra: map[string]any{"provider": p.Name, "issuer": p.Issuer, "jwks": source}})
+		providers = append(providers, NewProvider(p, keys))
 	}
-	return c, nil
+
+	return NewVerifier(providers...), nil
 }
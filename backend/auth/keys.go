@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v3/jwk"
+)
+
+// KeySource provides the signing keys of one issuer.
+type KeySource interface {
+	Lookup(ctx context.Context) (jwk.Set, error)
+	Refresh(ctx context.Context) (jwk.Set, error)
+}
+
+type remoteKeySource struct {
+	cache *jwk.Cache
+	url   string
+}
+
+// NewRemoteKeySource registers url in the shared cache, keys are refreshed in the background.
+func NewRemoteKeySource(ctx context.Context, cache *jwk.Cache, url string) (KeySource, error) {
+	if err := cache.Register(ctx, url); err != nil {
+		return nil, err
+	}
+	return &remoteKeySource{cache: cache, url: url}, nil
+}
+
+func (s *remoteKeySource) Lookup(ctx context.Context) (jwk.Set, error) {
+	return s.cache.Lookup(ctx, s.url)
+}
+
+func (s *remoteKeySource) Refresh(ctx context.Context) (jwk.Set, error) {
+	return s.cache.Refresh(ctx, s.url)
+}
+
+type staticKeySource struct {
+	set jwk.Set
+}
+
+// NewStaticKeySource serves keys from a JWKS file, for air-gapped and test setups.
+func NewStaticKeySource(path string) (KeySource, error) {
+	set, err := jwk.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &staticKeySource{set: set}, nil
+}
+
+func (s *staticKeySource) Lookup(context.Context) (jwk.Set, error) {
+	return s.set, nil
+}
+
+func (s *staticKeySource) Refresh(context.Context) (jwk.Set, error) {
+	return s.set, nil
+}
+
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// DiscoverJWKSURL reads jwks_uri from the issuer's .well-known/openid-configuration.
+func DiscoverJWKSURL(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discovery for %s returned %s", issuer, resp.Status)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return "", fmt.Errorf("discovery document issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document for %s has no jwks_uri", issuer)
+	}
+	return doc.JWKSURI, nil
+}
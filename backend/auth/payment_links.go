@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignPaymentLink returns the HMAC-SHA256 of the payment ID and expiry, base64url encoded for query strings.
+func SignPaymentLink(secret []byte, paymentID uuid.UUID, expiresAt time.Time) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(paymentID.String() + "." + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPaymentLink checks the signature in constant time and that the link has not expired.
+func VerifyPaymentLink(secret []byte, paymentID uuid.UUID, expiresAt time.Time, signature string) bool {
+	if !time.Now().Before(expiresAt) {
+		return false
+	}
+	expected := SignPaymentLink(secret, paymentID, expiresAt)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
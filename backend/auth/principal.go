@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+const principalContextKey = "principal"
+
+// Principal is the authenticated caller parsed from the access token.
+type Principal struct {
+	UserID      uuid.UUID
+	Issuer      string
+	ClientID    string
+	Scopes      []string
+	RealmRoles  []string
+	ClientRoles map[string][]string
+
+	// Profile claims, empty when the token doesn't carry them
+	Username string
+	Name     string
+	Email    string
+}
+
+func PrincipalFromClaims(claims jwt.MapClaims, mapping config.ClaimMapping) (*Principal, error) {
+	sub, ok := claimValue(claims, mapping.UserID).(string)
+	if !ok {
+		return nil, fmt.Errorf("missing %s claim", mapping.UserID)
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s claim: %w", mapping.UserID, err)
+	}
+
+	p := &Principal{
+		UserID:      userID,
+		Scopes:      stringList(claimValue(claims, mapping.Scopes)),
+		RealmRoles:  stringList(claimValue(claims, mapping.RealmRoles)),
+		ClientRoles: map[string][]string{},
+	}
+	p.ClientID, _ = claimValue(claims, mapping.ClientID).(string)
+	p.Username, _ = claimValue(claims, mapping.Username).(string)
+	p.Name, _ = claimValue(claims, mapping.Name).(string)
+	p.Email, _ = claimValue(claims, mapping.Email).(string)
+
+	// Keycloak layout: {"<client>": {"roles": [...]}}
+	if resources, ok := claimValue(claims, mapping.ClientRoles).(map[string]interface{}); ok {
+		for client, v := range resources {
+			if access, ok := v.(map[string]interface{}); ok {
+				p.ClientRoles[client] = stringList(access["roles"])
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// claimValue resolves dotted paths like realm_access.roles, empty path means the claim is not mapped.
+func claimValue(claims jwt.MapClaims, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var cur interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// stringList accepts both space separated strings (OAuth scope) and JSON arrays.
+func stringList(v interface{}) []string {
+	var out []string
+	switch val := v.(type) {
+	case string:
+		out = strings.Fields(val)
+	case []interface{}:
+		for _, s := range val {
+			if str, ok := s.(string); ok {
+				out = append(out, str)
+			}
+		}
+	case []string:
+		out = append(out, val...)
+	}
+	return out
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+func (p *Principal) HasRealmRole(role string) bool {
+	return slices.Contains(p.RealmRoles, role)
+}
+
+func (p *Principal) HasClientRole(client, role string) bool {
+	return slices.Contains(p.ClientRoles[client], role)
+}
+
+func SetPrincipal(c echo.Context, p *Principal) {
+	c.Set(principalContextKey, p)
+	c.Set("userID", p.UserID)
+	c.Set("clientID", p.ClientID)
+}
+
+func GetPrincipal(c echo.Context) *Principal {
+	p, _ := c.Get(principalContextKey).(*Principal)
+	return p
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ScopePaymentCreate = "payment_create"
+	ScopeAdmin         = "bank_admin"
+	RoleAdmin          = "bank_admin"
+	ScopeTreasury      = "bank_treasury"
+	RoleTreasury       = "bank_treasury"
+)
+
+// Requirement describes what a principal needs to access a route.
+type Requirement interface {
+	Allows(p *Principal) bool
+	String() string
+}
+
+type authenticated struct{}
+
+func (authenticated) Allows(p *Principal) bool { return p != nil }
+func (authenticated) String() string           { return "authenticated" }
+
+// Authenticated accepts any valid token.
+func Authenticated() Requirement {
+	return authenticated{}
+}
+
+type scope string
+
+func (s scope) Allows(p *Principal) bool { return p != nil && p.HasScope(string(s)) }
+func (s scope) String() string           { return "scope:" + string(s) }
+
+func Scope(name string) Requirement {
+	return scope(name)
+}
+
+type realmRole string
+
+func (r realmRole) Allows(p *Principal) bool { return p != nil && p.HasRealmRole(string(r)) }
+func (r realmRole) String() string           { return "realm_role:" + string(r) }
+
+func RealmRole(name string) Requirement {
+	return realmRole(name)
+}
+
+type clientRole struct {
+	client string
+	role   string
+}
+
+func (r clientRole) Allows(p *Principal) bool { return p != nil && p.HasClientRole(r.client, r.role) }
+func (r clientRole) String() string           { return fmt.Sprintf("client_role:%s/%s", r.client, r.role) }
+
+func ClientRole(client, role string) Requirement {
+	return clientRole{client: client, role: role}
+}
+
+type allOf []Requirement
+
+func (a allOf) Allows(p *Principal) bool {
+	for _, r := range a {
+		if !r.Allows(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a allOf) String() string { return join("AND", a) }
+
+// AllOf requires every requirement to pass.
+func AllOf(reqs ...Requirement) Requirement {
+	return allOf(reqs)
+}
+
+type anyOf []Requirement
+
+func (a anyOf) Allows(p *Principal) bool {
+	for _, r := range a {
+		if r.Allows(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a anyOf) String() string { return join("OR", a) }
+
+// AnyOf requires at least one requirement to pass.
+func AnyOf(reqs ...Requirement) Requirement {
+	return anyOf(reqs)
+}
+
+func join(op string, reqs []Requirement) string {
+	parts := make([]string, len(reqs))
+	for i, r := range reqs {
+		parts[i] = r.String()
+	}
+	return "(" + strings.Join(parts, " "+op+" ") + ")"
+}
+
+// Admin accepts either the admin scope (service clients) or the admin realm role (people).
+func Admin() Requirement {
+	return AnyOf(Scope(ScopeAdmin), RealmRole(RoleAdmin))
+}
+
+// Treasury guards minting and burning, admins don't get it implicitly.
+func Treasury() Requirement {
+	return AnyOf(Scope(ScopeTreasury), RealmRole(RoleTreasury))
+}
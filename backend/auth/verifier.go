@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+// keyRefreshInterval is how often a token with an unknown kid may force a JWKS fetch, so
+// unauthenticated callers can't make the server hit the issuer on every request.
+const keyRefreshInterval = 30 * time.Second
+
+// Provider is one trusted issuer with its keys and validation rules.
+type Provider struct {
+	cfg    config.OIDCProvider
+	keys   KeySource
+	parser *jwt.Parser
+
+	refreshMu   sync.Mutex
+	refreshedAt time.Time
+}
+
+func NewProvider(cfg config.OIDCProvider, keys KeySource) *Provider {
+	opts := []jwt.ParserOption{
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithLeeway(cfg.LeewayDuration),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	if len(cfg.Audiences) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.Audiences...))
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		keys:   keys,
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// Verifier picks the provider by the token issuer and checks signature, algorithm,
+// audience, authorized party and time claims.
+type Verifier struct {
+	providers  map[string]*Provider
+	unverified *jwt.Parser
+}
+
+func NewVerifier(providers ...*Provider) *Verifier {
+	v := &Verifier{
+		providers:  map[string]*Provider{},
+		unverified: jwt.NewParser(),
+	}
+	for _, p := range providers {
+		v.providers[p.cfg.Issuer] = p
+	}
+	return v
+}
+
+// Verify returns the caller principal or a *TokenError describing why the token was rejected.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Principal, error) {
+	peek := jwt.MapClaims{}
+	if _, _, err := v.unverified.ParseUnverified(tokenString, peek); err != nil {
+		return nil, reject(ReasonMalformed, err)
+	}
+	iss, _ := peek["iss"].(string)
+	if iss == "" {
+		return nil, reject(ReasonMissingClaim, fmt.Errorf("iss claim is required"))
+	}
+	provider, ok := v.providers[iss]
+	if !ok {
+		return nil, reject(ReasonIssuerMismatch, fmt.Errorf("issuer %q is not trusted", iss))
+	}
+
+	return provider.verify(ctx, tokenString)
+}
+
+func (p *Provider) verify(ctx context.Context, tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	token, err := p.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return p.keyFor(ctx, token)
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+	if !token.Valid {
+		return nil, reject(ReasonInvalidSignature, nil)
+	}
+
+	principal, err := PrincipalFromClaims(claims, p.cfg.Claims)
+	if err != nil {
+		return nil, reject(ReasonMissingClaim, err)
+	}
+	principal.Issuer = p.cfg.Issuer
+
+	if len(p.cfg.AuthorizedParties) > 0 && !slices.Contains(p.cfg.AuthorizedParties, principal.ClientID) {
+		return nil, reject(ReasonUnauthorizedParty, fmt.Errorf("client %q is not allowed", principal.ClientID))
+	}
+
+	return principal, nil
+}
+
+func (p *Provider) keyFor(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+	if !slices.Contains(p.cfg.Algorithms, alg) {
+		return nil, reject(ReasonAlgorithmNotAllowed, fmt.Errorf("algorithm %s is not allowed", alg))
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, reject(ReasonUnknownKey, fmt.Errorf("missing kid header"))
+	}
+
+	set, err := p.keys.Lookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := set.LookupKeyID(kid)
+	if !ok {
+		// Keys may have been rotated since the last fetch
+		if !p.mayRefresh() {
+			return nil, reject(ReasonUnknownKey, fmt.Errorf("unable to find JWK for kid %s", kid))
+		}
+		set, err = p.keys.Refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok = set.LookupKeyID(kid); !ok {
+			return nil, reject(ReasonUnknownKey, fmt.Errorf("unable to find JWK for kid %s", kid))
+		}
+	}
+
+	if keyAlg, ok := key.Algorithm(); ok && keyAlg.String() != alg {
+		return nil, reject(ReasonAlgorithmNotAllowed, fmt.Errorf("key %s is for %s, token uses %s", kid, keyAlg, alg))
+	}
+
+	return rawPublicKey(key)
+}
+
+// mayRefresh allows one forced key refresh per keyRefreshInterval.
+func (p *Provider) mayRefresh() bool {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.refreshedAt) < keyRefreshInterval {
+		return false
+	}
+	p.refreshedAt = now
+	return true
+}
+
+func rawPublicKey(key jwk.Key) (interface{}, error) {
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := jwk.PublicRawKeyOf(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := raw.(type) {
+	case *rsa.PublicKey:
+		return k, nil
+	case rsa.PublicKey:
+		return &k, nil
+	case *rsa.PrivateKey:
+		return &k.PublicKey, nil
+	case rsa.PrivateKey:
+		return &k.PublicKey, nil
+	case *ecdsa.PublicKey:
+		return k, nil
+	case ecdsa.PublicKey:
+		return &k, nil
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey, nil
+	case ecdsa.PrivateKey:
+		return &k.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", raw)
+	}
+}
@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/httprc/v3"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+const testIssuer = "https://sso.example.test/realms/bank"
+
+type testKeys struct {
+	rsa   *rsa.PrivateKey
+	ecdsa *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key: %v", err)
+	}
+	return testKeys{rsa: rsaKey, ecdsa: ecKey}
+}
+
+func serveJWKS(t *testing.T, keys testKeys) *httptest.Server {
+	t.Helper()
+	set := jwk.NewSet()
+	for kid, pub := range map[string]any{"rsa-1": &keys.rsa.PublicKey, "ec-1": &keys.ecdsa.PublicKey} {
+		key, err := jwk.Import(pub)
+		if err != nil {
+			t.Fatalf("import %s: %v", kid, err)
+		}
+		if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+			t.Fatalf("set kid: %v", err)
+		}
+		alg := jwa.RS256()
+		if kid == "ec-1" {
+			alg = jwa.ES256()
+		}
+		if err := key.Set(jwk.AlgorithmKey, alg); err != nil {
+			t.Fatalf("set alg: %v", err)
+		}
+		if err := set.AddKey(key); err != nil {
+			t.Fatalf("add key: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testProvider(jwksURL string) config.OIDCProvider {
+	return config.OIDCProvider{
+		Name:              "keycloak",
+		Issuer:            testIssuer,
+		JWKSURL:           jwksURL,
+		Audiences:         []string{"bank"},
+		AuthorizedParties: []string{"bank-frontend"},
+		Algorithms:        []string{"RS256", "ES256"},
+		LeewayDuration:    30 * time.Second,
+		Claims: config.ClaimMapping{
+			UserID:      "sub",
+			Scopes:      "scope",
+			ClientID:    "azp",
+			RealmRoles:  "realm_access.roles",
+			ClientRoles: "resource_access",
+		},
+	}
+}
+
+func newTestVerifier(t *testing.T, srv *httptest.Server, mutate func(cfg *config.OIDCProvider)) *Verifier {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cache, err := jwk.NewCache(ctx, httprc.NewClient())
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	keys, err := NewRemoteKeySource(ctx, cache, srv.URL)
+	if err != nil {
+		t.Fatalf("register jwks: %v", err)
+	}
+
+	cfg := testProvider(srv.URL)
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	return NewVerifier(NewProvider(cfg, keys))
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": testIssuer,
+		"sub": "8a4f6f1e-5b1a-4c43-9a39-36f3a8c0b7a1",
+		"aud": "bank",
+		"azp": "bank-frontend",
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func TestVerifierAcceptsValidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, serveJWKS(t, keys), nil)
+
+	tests := map[string]string{
+		"rsa":   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, validClaims()),
+		"ecdsa": sign(t, jwt.SigningMethodES256, "ec-1", keys.ecdsa, validClaims()),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			principal, err := v.Verify(context.Background(), token)
+			if err != nil {
+				t.Fatalf("expected token to be accepted, got %v", err)
+			}
+			if principal.UserID.String() != validClaims()["sub"] {
+				t.Fatalf("unexpected user ID %s", principal.UserID)
+			}
+		})
+	}
+}
+
+func TestVerifierAcceptsClockSkewWithinLeeway(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, serveJWKS(t, keys), nil)
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	claims["iat"] = time.Now().Add(10 * time.Second).Unix()
+	claims["nbf"] = time.Now().Add(10 * time.Second).Unix()
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, claims)); err != nil {
+		t.Fatalf("expected token within leeway to be accepted, got %v", err)
+	}
+}
+
+func TestVerifierRejections(t *testing.T) {
+	keys := newTestKeys(t)
+	srv := serveJWKS(t, keys)
+	v := newTestVerifier(t, srv, nil)
+
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		reason RejectReason
+	}{
+		{"malformed", "not.a.jwt", ReasonMalformed},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("iss", "https://evil.example.test/realms/bank")), ReasonIssuerMismatch},
+		{"missing issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("iss", nil)), ReasonMissingClaim},
+		{"missing sub", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("sub", nil)), ReasonMissingClaim},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("aud", "account")), ReasonAudienceMismatch},
+		{"wrong azp", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("azp", "other-client")), ReasonUnauthorizedParty},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("exp", time.Now().Add(-time.Minute).Unix())), ReasonExpired},
+		{"missing exp", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("exp", nil)), ReasonMissingClaim},
+		{"not yet valid", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("nbf", time.Now().Add(time.Minute).Unix())), ReasonNotYetValid},
+		{"issued in future", sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, with("iat", time.Now().Add(time.Minute).Unix())), ReasonIssuedInFuture},
+		{"hmac algorithm", sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims()), ReasonAlgorithmNotAllowed},
+		{"algorithm not in allow-list", sign(t, jwt.SigningMethodRS512, "rsa-1", keys.rsa, validClaims()), ReasonAlgorithmNotAllowed},
+		{"algorithm differs from key", sign(t, jwt.SigningMethodES256, "rsa-1", keys.ecdsa, validClaims()), ReasonAlgorithmNotAllowed},
+		{"missing kid", sign(t, jwt.SigningMethodRS256, "", keys.rsa, validClaims()), ReasonUnknownKey},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "rsa-2", keys.rsa, validClaims()), ReasonUnknownKey},
+		{"foreign signature", sign(t, jwt.SigningMethodRS256, "rsa-1", otherRSA, validClaims()), ReasonInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if err == nil {
+				t.Fatalf("expected token to be rejected")
+			}
+			var tokenErr *TokenError
+			if !errors.As(err, &tokenErr) {
+				t.Fatalf("expected *TokenError, got %T: %v", err, err)
+			}
+			if tokenErr.Reason != tt.reason {
+				t.Fatalf("expected reason %s, got %s (%v)", tt.reason, tokenErr.Reason, err)
+			}
+		})
+	}
+}
+
+func TestVerifierOptionalChecks(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, serveJWKS(t, keys), func(cfg *config.OIDCProvider) {
+		cfg.Audiences = nil
+		cfg.AuthorizedParties = nil
+	})
+
+	claims := validClaims()
+	claims["aud"] = "account"
+	claims["azp"] = "any-client"
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "ec-1", keys.ecdsa, claims)); err != nil {
+		t.Fatalf("expected token to be accepted without audience and azp allow-lists, got %v", err)
+	}
+}
+
+func TestVerifierMultipleIssuersWithStaticJWKSAndClaimMapping(t *testing.T) {
+	keys := newTestKeys(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cache, err := jwk.NewCache(ctx, httprc.NewClient())
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	remote, err := NewRemoteKeySource(ctx, cache, serveJWKS(t, keys).URL)
+	if err != nil {
+		t.Fatalf("register jwks: %v", err)
+	}
+
+	// Second issuer signs with its own key, published only as a local file
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ecdsa key: %v", err)
+	}
+	pub, err := jwk.Import(&otherKey.PublicKey)
+	if err != nil {
+		t.Fatalf("import key: %v", err)
+	}
+	_ = pub.Set(jwk.KeyIDKey, "static-1")
+	set := jwk.NewSet()
+	_ = set.AddKey(pub)
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	static, err := NewStaticKeySource(path)
+	if err != nil {
+		t.Fatalf("static key source: %v", err)
+	}
+
+	const otherIssuer = "https://idp.example.test"
+	v := NewVerifier(
+		NewProvider(testProvider(""), remote),
+		NewProvider(config.OIDCProvider{
+			Name:           "other",
+			Issuer:         otherIssuer,
+			Audiences:      []string{"bank-api"},
+			Algorithms:     []string{"ES256"},
+			LeewayDuration: time.Second,
+			Claims: config.ClaimMapping{
+				UserID:     "uid",
+				Scopes:     "permissions",
+				ClientID:   "client_id",
+				RealmRoles: "groups",
+			},
+		}, static),
+	)
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, validClaims())); err != nil {
+		t.Fatalf("expected keycloak token to be accepted, got %v", err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":         otherIssuer,
+		"sub":         "service-account",
+		"uid":         "0f5b8f8e-2b4e-4d8e-9d6c-3c1c8f2b9a10",
+		"aud":         []string{"bank-api"},
+		"client_id":   "shop",
+		"permissions": []string{"payment_create"},
+		"groups":      []string{"merchants"},
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Minute).Unix(),
+	}
+	principal, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "static-1", otherKey, claims))
+	if err != nil {
+		t.Fatalf("expected mapped token to be accepted, got %v", err)
+	}
+	if principal.UserID.String() != claims["uid"] || principal.ClientID != "shop" || principal.Issuer != otherIssuer {
+		t.Fatalf("unexpected principal %+v", principal)
+	}
+	if !principal.HasScope("payment_create") || !principal.HasRealmRole("merchants") {
+		t.Fatalf("claim mapping not applied: %+v", principal)
+	}
+
+	// A key of one issuer must not validate tokens claiming to be from the other one
+	claims["iss"] = testIssuer
+	_, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "static-1", otherKey, claims))
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Reason != ReasonUnknownKey {
+		t.Fatalf("expected unknown_key, got %v", err)
+	}
+}
+
+func TestDiscoverJWKSURL(t *testing.T) {
+	var srv *httptest.Server
+	issuerPath := "/realms/bank"
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != issuerPath+"/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   srv.URL + issuerPath,
+			"jwks_uri": srv.URL + issuerPath + "/protocol/openid-connect/certs",
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := DiscoverJWKSURL(context.Background(), srv.Client(), srv.URL+issuerPath)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if want := srv.URL + issuerPath + "/protocol/openid-connect/certs"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	if _, err := DiscoverJWKSURL(context.Background(), srv.Client(), srv.URL+"/realms/other"); err == nil {
+		t.Fatalf("expected discovery of unknown issuer to fail")
+	}
+}
+
+type countingKeySource struct {
+	KeySource
+	refreshes int
+}
+
+func (s *countingKeySource) Refresh(ctx context.Context) (jwk.Set, error) {
+	s.refreshes++
+	return s.KeySource.Refresh(ctx)
+}
+
+func TestVerifierLimitsForcedKeyRefreshes(t *testing.T) {
+	keys := newTestKeys(t)
+	srv := serveJWKS(t, keys)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cache, err := jwk.NewCache(ctx, httprc.NewClient())
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	remote, err := NewRemoteKeySource(ctx, cache, srv.URL)
+	if err != nil {
+		t.Fatalf("register jwks: %v", err)
+	}
+	source := &countingKeySource{KeySource: remote}
+	v := NewVerifier(NewProvider(testProvider(srv.URL), source))
+
+	for range 5 {
+		if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-2", keys.rsa, validClaims())); err == nil {
+			t.Fatalf("expected token with unknown kid to be rejected")
+		}
+	}
+	if source.refreshes != 1 {
+		t.Fatalf("expected 1 forced refresh, got %d", source.refreshes)
+	}
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, validClaims())); err != nil {
+		t.Fatalf("expected known key to keep working, got %v", err)
+	}
+}
@@ -5,17 +5,29 @@ import (
 )
 
 type Config struct {
-	WebAppConfig   *utilsConfig.WebAppConfig
-	PGConfig       *utilsConfig.PGConfig
-	KeyCloakConfig *KeyCloakConfig
+	WebAppConfig       *utilsConfig.WebAppConfig
+	PGConfig           *utilsConfig.PGConfig
+	KeyCloakConfig     *KeyCloakConfig
+	OIDCConfig         *OIDCConfig
+	RateLimitConfig    *RateLimitConfig
+	JobsConfig         *JobsConfig
+	ProfileConfig      *ProfileConfig
+	PaymentLinkConfig  *PaymentLinkConfig
+	MoneyRequestConfig *MoneyRequestConfig
 }
 
 func BuildConfigFromEnv() (*Config, error) {
 	config := &Config{
-		WebAppConfig:   utilsConfig.LoadWebAppConfigFromEnv(),
-		PGConfig:       utilsConfig.LoadPGConfigFromEnv(),
-		KeyCloakConfig: LoadKeyCloakConfigFromEnv(),
+		WebAppConfig:       utilsConfig.LoadWebAppConfigFromEnv(),
+		PGConfig:           utilsConfig.LoadPGConfigFromEnv(),
+		KeyCloakConfig:     LoadKeyCloakConfigFromEnv(),
+		RateLimitConfig:    LoadRateLimitConfigFromEnv(),
+		JobsConfig:         LoadJobsConfigFromEnv(),
+		ProfileConfig:      LoadProfileConfigFromEnv(),
+		PaymentLinkConfig:  LoadPaymentLinkConfigFromEnv(),
+		MoneyRequestConfig: LoadMoneyRequestConfigFromEnv(),
 	}
+	config.OIDCConfig = LoadOIDCConfigFromEnv(config.KeyCloakConfig)
 
 	return config, nil
 }
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"log"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+type JobsConfig struct {
+	Enabled                 bool          `env:"JOBS_ENABLED" envDefault:"true"`
+	ExpireTransfersInterval time.Duration `env:"JOBS_EXPIRE_TRANSFERS_INTERVAL" envDefault:"1m"`
+	SyncDisabledInterval    time.Duration `env:"JOBS_SYNC_DISABLED_USERS_INTERVAL" envDefault:"15m"`
+	BalanceSnapshotInterval time.Duration `env:"JOBS_BALANCE_SNAPSHOT_INTERVAL" envDefault:"1h"`
+	RollupInterval          time.Duration `env:"JOBS_ROLLUP_INTERVAL" envDefault:"5m"`
+	RemindersInterval       time.Duration `env:"JOBS_PAYMENT_REMINDERS_INTERVAL" envDefault:"10m"`
+}
+
+func LoadJobsConfigFromEnv() *JobsConfig {
+	config := &JobsConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	return config
+}
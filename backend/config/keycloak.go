@@ -3,15 +3,27 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
 type KeyCloakConfig struct {
-	Realm      string `env:"KEYCLOAK_REALM"`
-	AuthServer string `env:"KEYCLOAK_AUTH_SERVER"`
-	ISSUER_URL string `env:"KEYCLOAK_ISSUER_URL" envDefault:""`
-	URL        string
+	Realm             string        `env:"KEYCLOAK_REALM"`
+	AuthServer        string        `env:"KEYCLOAK_AUTH_SERVER"`
+	ISSUER_URL        string        `env:"KEYCLOAK_ISSUER_URL" envDefault:""`
+	Audiences         []string      `env:"KEYCLOAK_AUDIENCE" envSeparator:","`
+	AuthorizedParties []string      `env:"KEYCLOAK_AUTHORIZED_PARTIES" envSeparator:","`
+	Algorithms        []string      `env:"KEYCLOAK_ALLOWED_ALGS" envSeparator:"," envDefault:"RS256,RS384,RS512,PS256,PS384,PS512,ES256,ES384,ES512"`
+	Leeway            time.Duration `env:"KEYCLOAK_LEEWAY" envDefault:"30s"`
+	URL               string
+
+	// Admin REST API access through a service account, used to check recipients
+	AdminEnabled      bool          `env:"KEYCLOAK_ADMIN_ENABLED" envDefault:"false"`
+	AdminClientID     string        `env:"KEYCLOAK_ADMIN_CLIENT_ID"`
+	AdminClientSecret string        `env:"KEYCLOAK_ADMIN_CLIENT_SECRET"`
+	AdminTimeout      time.Duration `env:"KEYCLOAK_ADMIN_TIMEOUT" envDefault:"5s"`
+	UserCacheTTL      time.Duration `env:"KEYCLOAK_USER_CACHE_TTL" envDefault:"5m"`
 }
 
 func LoadKeyCloakConfigFromEnv() *KeyCloakConfig {
@@ -23,5 +35,8 @@ func LoadKeyCloakConfigFromEnv() *KeyCloakConfig {
 		config.ISSUER_URL = fmt.Sprintf("%s/realms/%s", config.AuthServer, config.Realm)
 	}
 	config.URL = fmt.Sprintf("%s/realms/%s/protocol/openid-connect/certs", config.AuthServer, config.Realm)
+	if config.AdminEnabled && (config.AdminClientID == "" || config.AdminClientSecret == "") {
+		log.Fatalf("KEYCLOAK_ADMIN_CLIENT_ID and KEYCLOAK_ADMIN_CLIENT_SECRET are required when KEYCLOAK_ADMIN_ENABLED is set")
+	}
 	return config
 }
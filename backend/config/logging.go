@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+
+	"github.com/caarlos0/env/v11"
+)
+
+type LoggingConfig struct {
+	Format       string            `env:"LOG_FORMAT" envDefault:"text"`
+	Level        string            `env:"LOG_LEVEL" envDefault:"debug"`
+	TypeLevels   map[string]string `env:"LOG_TYPE_LEVELS" envSeparator:"," envKeyValSeparator:"="`
+	RedactFields []string          `env:"LOG_REDACT_FIELDS" envSeparator:","`
+}
+
+func LoadLoggingConfigFromEnv() *LoggingConfig {
+	config := &LoggingConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	return config
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// MoneyRequestConfig limits money requests between users and their reminders.
+type MoneyRequestConfig struct {
+	MaxOpen          int           `env:"MONEY_REQUEST_MAX_OPEN" envDefault:"10"`
+	ReminderInterval time.Duration `env:"MONEY_REQUEST_REMINDER_INTERVAL" envDefault:"24h"`
+	MaxReminders     int           `env:"MONEY_REQUEST_MAX_REMINDERS" envDefault:"3"`
+}
+
+func LoadMoneyRequestConfigFromEnv() *MoneyRequestConfig {
+	config := &MoneyRequestConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if config.MaxOpen <= 0 {
+		log.Fatalf("MONEY_REQUEST_MAX_OPEN must be positive")
+	}
+	return config
+}
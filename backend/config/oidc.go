@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// ClaimMapping tells which claims hold the user ID, scopes, client ID, roles and profile.
+// Nested claims are addressed with dots, e.g. realm_access.roles.
+type ClaimMapping struct {
+	UserID      string `json:"user_id"`
+	Scopes      string `json:"scopes"`
+	ClientID    string `json:"client_id"`
+	RealmRoles  string `json:"realm_roles"`
+	ClientRoles string `json:"client_roles"`
+	Username    string `json:"username"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+}
+
+type OIDCProvider struct {
+	Name              string       `json:"name"`
+	Issuer            string       `json:"issuer"`
+	Audiences         []string     `json:"audiences"`
+	AuthorizedParties []string     `json:"authorized_parties"`
+	Algorithms        []string     `json:"algorithms"`
+	Leeway            string       `json:"leeway"`
+	JWKSURL           string       `json:"jwks_url"`
+	JWKSFile          string       `json:"jwks_file"`
+	Claims            ClaimMapping `json:"claims"`
+
+	LeewayDuration time.Duration `json:"-"`
+}
+
+type OIDCConfig struct {
+	ProvidersFile string `env:"OIDC_PROVIDERS_FILE"`
+	ProvidersJSON string `env:"OIDC_PROVIDERS"`
+	Providers     []OIDCProvider
+}
+
+var defaultAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var keycloakClaims = ClaimMapping{
+	UserID:      "sub",
+	Scopes:      "scope",
+	ClientID:    "azp",
+	RealmRoles:  "realm_access.roles",
+	ClientRoles: "resource_access",
+	Username:    "preferred_username",
+	Name:        "name",
+	Email:       "email",
+}
+
+// LoadOIDCConfigFromEnv reads trusted issuers from OIDC_PROVIDERS_FILE or OIDC_PROVIDERS (JSON array).
+// Without them the Keycloak realm from KEYCLOAK_* variables is the only trusted issuer.
+func LoadOIDCConfigFromEnv(keycloak *KeyCloakConfig) *OIDCConfig {
+	config := &OIDCConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	raw := []byte(config.ProvidersJSON)
+	if config.ProvidersFile != "" {
+		data, err := os.ReadFile(config.ProvidersFile)
+		if err != nil {
+			log.Fatalf("Failed to read OIDC providers file: %v", err)
+		}
+		raw = data
+	}
+
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &config.Providers); err != nil {
+			log.Fatalf("Failed to parse OIDC providers: %v", err)
+		}
+	} else {
+		config.Providers = []OIDCProvider{keycloakProvider(keycloak)}
+	}
+
+	if err := config.normalize(); err != nil {
+		log.Fatalf("Invalid OIDC providers: %v", err)
+	}
+	return config
+}
+
+func keycloakProvider(cfg *KeyCloakConfig) OIDCProvider {
+	return OIDCProvider{
+		Name:              "keycloak",
+		Issuer:            cfg.ISSUER_URL,
+		Audiences:         cfg.Audiences,
+		AuthorizedParties: cfg.AuthorizedParties,
+		Algorithms:        cfg.Algorithms,
+		JWKSURL:           cfg.URL,
+		Claims:            keycloakClaims,
+		LeewayDuration:    cfg.Leeway,
+	}
+}
+
+func (c *OIDCConfig) normalize() error {
+	if len(c.Providers) == 0 {
+		return fmt.Errorf("no providers configured")
+	}
+
+	seen := map[string]struct{}{}
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		if p.Issuer == "" {
+			return fmt.Errorf("provider %d: issuer is required", i)
+		}
+		if _, ok := seen[p.Issuer]; ok {
+			return fmt.Errorf("provider %d: duplicate issuer %s", i, p.Issuer)
+		}
+		seen[p.Issuer] = struct{}{}
+
+		if p.Name == "" {
+			p.Name = p.Issuer
+		}
+		if len(p.Algorithms) == 0 {
+			p.Algorithms = defaultAlgorithms
+		}
+		if p.Leeway != "" {
+			d, err := time.ParseDuration(p.Leeway)
+			if err != nil {
+				return fmt.Errorf("provider %s: invalid leeway: %w", p.Name, err)
+			}
+			p.LeewayDuration = d
+		} else if p.LeewayDuration == 0 {
+			p.LeewayDuration = 30 * time.Second
+		}
+
+		if p.Claims.UserID == "" {
+			p.Claims.UserID = keycloakClaims.UserID
+		}
+		if p.Claims.Scopes == "" {
+			p.Claims.Scopes = keycloakClaims.Scopes
+		}
+		if p.Claims.ClientID == "" {
+			p.Claims.ClientID = keycloakClaims.ClientID
+		}
+		if p.Claims.Username == "" {
+			p.Claims.Username = keycloakClaims.Username
+		}
+		if p.Claims.Name == "" {
+			p.Claims.Name = keycloakClaims.Name
+		}
+		if p.Claims.Email == "" {
+			p.Claims.Email = keycloakClaims.Email
+		}
+	}
+	return nil
+}
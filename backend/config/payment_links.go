@@ -0,0 +1,38 @@
+package config
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// PaymentLinkConfig signs shareable payment links, without a secret links and QR codes are disabled.
+type PaymentLinkConfig struct {
+	Secret  string        `env:"PAYMENT_LINK_SECRET"`
+	BaseURL string        `env:"PAYMENT_LINK_BASE_URL"`
+	TTL     time.Duration `env:"PAYMENT_LINK_TTL" envDefault:"720h"`
+	QRSize  int           `env:"PAYMENT_LINK_QR_SIZE" envDefault:"256"`
+}
+
+func (c *PaymentLinkConfig) Enabled() bool {
+	return c.Secret != ""
+}
+
+func LoadPaymentLinkConfigFromEnv() *PaymentLinkConfig {
+	config := &PaymentLinkConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Enabled() {
+		if len(config.Secret) < 32 {
+			log.Fatalf("PAYMENT_LINK_SECRET must be at least 32 characters long")
+		}
+		if config.BaseURL == "" {
+			log.Fatalf("PAYMENT_LINK_BASE_URL is required when PAYMENT_LINK_SECRET is set")
+		}
+	}
+	return config
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"log"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// StatsPeriods are the named windows profile totals can be computed for, zero means all time.
+var StatsPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+type ProfileConfig struct {
+	StatsPeriods     []string `env:"PROFILE_STATS_PERIODS" envSeparator:"," envDefault:"day,week,month"`
+	HistoryMaxPoints int      `env:"PROFILE_HISTORY_MAX_POINTS" envDefault:"366"`
+}
+
+func LoadProfileConfigFromEnv() *ProfileConfig {
+	config := &ProfileConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	for _, p := range config.StatsPeriods {
+		if _, ok := StatsPeriods[p]; !ok {
+			log.Fatalf("Unknown period %q in PROFILE_STATS_PERIODS", p)
+		}
+	}
+	return config
+}
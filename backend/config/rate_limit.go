@@ -0,0 +1,27 @@
+package config
+
+import (
+	"log"
+
+	"github.com/caarlos0/env/v11"
+)
+
+type RateLimitConfig struct {
+	Enabled           bool    `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+	Backend           string  `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	ReadPerMinute     float64 `env:"RATE_LIMIT_READ_PER_MINUTE" envDefault:"120"`
+	ReadBurst         int64   `env:"RATE_LIMIT_READ_BURST" envDefault:"60"`
+	TransferPerMinute float64 `env:"RATE_LIMIT_TRANSFER_PER_MINUTE" envDefault:"20"`
+	TransferBurst     int64   `env:"RATE_LIMIT_TRANSFER_BURST" envDefault:"10"`
+	PaymentPerMinute  float64 `env:"RATE_LIMIT_PAYMENT_PER_MINUTE" envDefault:"30"`
+	PaymentBurst      int64   `env:"RATE_LIMIT_PAYMENT_BURST" envDefault:"15"`
+	ClientMultiplier  float64 `env:"RATE_LIMIT_CLIENT_MULTIPLIER" envDefault:"100"`
+}
+
+func LoadRateLimitConfigFromEnv() *RateLimitConfig {
+	config := &RateLimitConfig{}
+	if err := env.Parse(config); err != nil {
+		log.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	return config
+}
@@ -14,6 +14,7 @@ require (
 	github.com/lestrrat-go/jwx/v3 v3.0.13
 	github.com/nrf24l01/go-logger v1.1.1
 	github.com/nrf24l01/go-web-utils v1.12.3
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
 
 require (
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) CreateAccountHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateAccountRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	account, err := postgres.CreateOrganizationAccount(h.DB, c.Request().Context(), req.Name, userID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create account", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create account", nil))
+	}
+
+	return c.JSON(http.StatusCreated, account.ToAccountFull())
+}
+
+func (h *Handler) ListAccountsHandler(c echo.Context) error {
+	userID := c.Get("userID").(uuid.UUID)
+
+	accounts, err := postgres.ListAccountsForUser(h.DB, c.Request().Context(), userID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list accounts", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list accounts", nil))
+	}
+
+	resp := []schemas.AccountFull{}
+	for _, a := range accounts {
+		resp = append(resp, a.ToAccountFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetAccountHandler(c echo.Context) error {
+	accountID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid account ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	account, err := postgres.GetAccountForUser(h.DB, c.Request().Context(), accountID, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "account not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get account", nil))
+	}
+
+	return c.JSON(http.StatusOK, account.ToAccountFull())
+}
+
+func (h *Handler) ListAccountMembersHandler(c echo.Context) error {
+	accountID, ok, err := h.requireAccountRole(c)
+	if !ok {
+		return err
+	}
+
+	members, err := postgres.ListAccountMembers(h.DB, c.Request().Context(), accountID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list account members", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list account members", nil))
+	}
+
+	resp := []schemas.AccountMemberFull{}
+	for _, m := range members {
+		resp = append(resp, m.ToAccountMemberFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) SetAccountMemberHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.SetAccountMemberRequest)
+	accountID, ok, err := h.requireAccountRole(c, schemas.RoleOwner)
+	if !ok {
+		return err
+	}
+	if accountID == c.Get("userID").(uuid.UUID) {
+		return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "personal accounts can not have members", nil))
+	}
+
+	member, err := postgres.SetAccountMember(h.DB, c.Request().Context(), accountID, req.UserID, req.Role)
+	if err != nil {
+		if err == postgres.ErrLastOwner {
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "account must keep at least one owner", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to set account member", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to set account member", nil))
+	}
+
+	return c.JSON(http.StatusOK, member.ToAccountMemberFull())
+}
+
+func (h *Handler) RemoveAccountMemberHandler(c echo.Context) error {
+	memberID, err := uuid.Parse(c.Param("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid user ID", nil))
+	}
+	accountID, ok, err := h.requireAccountRole(c, schemas.RoleOwner)
+	if !ok {
+		return err
+	}
+
+	if err := postgres.RemoveAccountMember(h.DB, c.Request().Context(), accountID, memberID); err != nil {
+		if err == postgres.ErrLastOwner {
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "account must keep at least one owner", nil))
+		}
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "member not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to remove account member", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to remove account member", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) GetAccountTransactionsHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.GetTransactionsRequest)
+	accountID, ok, err := h.requireAccountRole(c)
+	if !ok {
+		return err
+	}
+
+	filter, ok, err := transactionFilter(c, req)
+	if !ok {
+		return err
+	}
+
+	transactions, err := postgres.GetTransactionsByUserID(h.DB, c.Request().Context(), accountID, filter, req.Size, (req.Page-1)*req.Size)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get transactions", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get transactions", nil))
+	}
+
+	resp := []schemas.TransactionFull{}
+	for _, t := range transactions {
+		resp = append(resp, t.ToTransactionFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// requireAccountRole resolves the :uuid account and checks the caller's role in it,
+// any membership is enough when no roles are given. When ok is false the response is already written.
+func (h *Handler) requireAccountRole(c echo.Context, roles ...schemas.AccountRole) (uuid.UUID, bool, error) {
+	accountID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return uuid.Nil, false, c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid account ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	role, err := postgres.GetAccountRole(h.DB, c.Request().Context(), accountID, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, false, c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "account not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account role", err)
+		return uuid.Nil, false, c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get account", nil))
+	}
+	if len(roles) == 0 {
+		return accountID, true, nil
+	}
+	for _, r := range roles {
+		if role == r {
+			return accountID, true, nil
+		}
+	}
+	return uuid.Nil, false, c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "insufficient account role", map[string]any{"role": role}))
+}
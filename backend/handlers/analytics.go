@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) GetAnalyticsHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.GetAnalyticsRequest)
+	uid := c.Get("userID").(uuid.UUID)
+
+	to := time.Now().UTC()
+	if req.To != nil {
+		to = req.To.UTC()
+	}
+	from := to.AddDate(0, 0, -30)
+	if req.From != nil {
+		from = req.From.UTC()
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "day"
+	}
+	groupBy := req.GroupBy
+	if groupBy == nil {
+		groupBy = []string{}
+	}
+
+	if from.After(to) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "from must not be after to", nil))
+	}
+	if points := historyPoints(from, to, granularity); points > h.Config.ProfileConfig.HistoryMaxPoints {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "range is too long for this granularity", map[string]any{
+			"points":     points,
+			"max_points": h.Config.ProfileConfig.HistoryMaxPoints,
+		}))
+	}
+
+	rows, err := postgres.GetAnalytics(h.DB, c.Request().Context(), uid, from, to, granularity,
+		slices.Contains(groupBy, "category"), slices.Contains(groupBy, "counterparty"))
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get analytics", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get analytics", nil))
+	}
+
+	resp := schemas.AnalyticsFull{
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Granularity: granularity,
+		GroupBy:     groupBy,
+		Rows:        make([]schemas.AnalyticsRowFull, 0, len(rows)),
+	}
+	for _, r := range rows {
+		resp.Rows = append(resp.Rows, r.ToAnalyticsRowFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) CreateApiKeyHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateApiKeyRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "expires_at must be in the future", nil))
+	}
+	if !canGrantScopes(c, req.Scopes) {
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "only treasurers can grant the treasury scope", nil))
+	}
+	if ok, err := h.checkKeyOwner(c, req.OwnerID, req.Scopes); !ok {
+		return err
+	}
+
+	plain, prefix, hash, err := auth.GenerateApiKey()
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeAuth, "Failed to generate api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to generate api key", nil))
+	}
+
+	key := postgres.ApiKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Owner:     req.OwnerID,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		Creator:   userID,
+	}
+	if err := key.Insert(h.DB, c.Request().Context()); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create api key", nil))
+	}
+
+	return c.JSON(http.StatusCreated, schemas.ApiKeyCreated{ApiKeyFull: key.ToApiKeyFull(), Key: plain})
+}
+
+func (h *Handler) ListApiKeysHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ListApiKeysRequest)
+
+	keys, err := postgres.ListApiKeys(h.DB, c.Request().Context(), req.Size, (req.Page-1)*req.Size)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list api keys", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list api keys", nil))
+	}
+
+	resp := []schemas.ApiKeyFull{}
+	for _, k := range keys {
+		resp = append(resp, k.ToApiKeyFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetApiKeyHandler(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid api key ID", nil))
+	}
+
+	key, err := postgres.GetApiKeyByID(h.DB, c.Request().Context(), keyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "api key not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get api key", nil))
+	}
+
+	return c.JSON(http.StatusOK, key.ToApiKeyFull())
+}
+
+func (h *Handler) RevokeApiKeyHandler(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid api key ID", nil))
+	}
+
+	if err := postgres.RevokeApiKey(h.DB, c.Request().Context(), keyID); err != nil {
+		if errors.Is(err, postgres.ErrApiKeyNotFound) {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "api key not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to revoke api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to revoke api key", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) RotateApiKeyHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.RotateApiKeyRequest)
+	userID := c.Get("userID").(uuid.UUID)
+	keyID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid api key ID", nil))
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "expires_at must be in the future", nil))
+	}
+
+	// The new key inherits the scopes, so rotating a treasury key hands out a treasury key
+	old, err := postgres.GetApiKeyByID(h.DB, c.Request().Context(), keyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "api key not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to rotate api key", nil))
+	}
+	if !canGrantScopes(c, old.Scopes) {
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "only treasurers can rotate keys with the treasury scope", nil))
+	}
+	if ok, err := h.checkKeyOwner(c, old.Owner, old.Scopes); !ok {
+		return err
+	}
+
+	plain, prefix, hash, err := auth.GenerateApiKey()
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeAuth, "Failed to generate api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to generate api key", nil))
+	}
+
+	next := postgres.ApiKey{Prefix: prefix, Hash: hash, Creator: userID, ExpiresAt: req.ExpiresAt}
+	if err := postgres.RotateApiKey(h.DB, c.Request().Context(), keyID, &next, time.Duration(req.GraceSeconds)*time.Second); err != nil {
+		if errors.Is(err, postgres.ErrApiKeyNotFound) {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "api key not found", nil))
+		}
+		if errors.Is(err, postgres.ErrApiKeyExpired) {
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "api key is expired, create a new one", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to rotate api key", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to rotate api key", nil))
+	}
+
+	return c.JSON(http.StatusCreated, schemas.ApiKeyCreated{ApiKeyFull: next.ToApiKeyFull(), Key: plain})
+}
+
+// canGrantScopes keeps admins from issuing themselves the treasury scope, only callers that
+// already pass the treasury requirement can hand it out.
+func canGrantScopes(c echo.Context, scopes []string) bool {
+	return !slices.Contains(scopes, auth.ScopeTreasury) || auth.Treasury().Allows(auth.GetPrincipal(c))
+}
+
+// spendingScopes let a key move the owner's money beyond what any authenticated caller can.
+var spendingScopes = []string{auth.ScopePaymentCreate, auth.ScopeTreasury}
+
+// checkKeyOwner keeps admins from acting as other users: keys belong to organization and system
+// accounts, a personal account only gets a key from its own owner or spender and never one with
+// a scope that spends money.
+func (h *Handler) checkKeyOwner(c echo.Context, owner uuid.UUID, scopes []string) (bool, error) {
+	kind, err := postgres.GetAccountKind(h.DB, c.Request().Context(), owner)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account kind", err)
+		return false, c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to check api key owner", nil))
+	}
+	if kind != schemas.AccountPersonal {
+		return true, nil
+	}
+
+	for _, s := range scopes {
+		if slices.Contains(spendingScopes, s) {
+			return false, c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "personal accounts can't own api keys that spend money", nil))
+		}
+	}
+
+	role, err := postgres.GetAccountRole(h.DB, c.Request().Context(), owner, c.Get("userID").(uuid.UUID))
+	if err != nil && err != pgx.ErrNoRows {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account role", err)
+		return false, c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to check api key owner", nil))
+	}
+	if role != schemas.RoleOwner && role != schemas.RoleSpender {
+		return false, c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "api keys for personal accounts can only be issued by their owner", nil))
+	}
+	return true, nil
+}
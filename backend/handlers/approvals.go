@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) GetApprovalPolicyHandler(c echo.Context) error {
+	accountID, ok, err := h.requireAccountRole(c)
+	if !ok {
+		return err
+	}
+
+	policy, err := postgres.GetApprovalPolicy(h.DB, c.Request().Context(), accountID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "approval policy not set", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get approval policy", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get approval policy", nil))
+	}
+
+	return c.JSON(http.StatusOK, policy.ToApprovalPolicyFull())
+}
+
+func (h *Handler) SetApprovalPolicyHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.SetApprovalPolicyRequest)
+	accountID, ok, err := h.requireAccountRole(c, schemas.RoleOwner)
+	if !ok {
+		return err
+	}
+	if accountID == c.Get("userID").(uuid.UUID) {
+		return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "personal accounts can not have approval policies", nil))
+	}
+
+	policy := postgres.ApprovalPolicy{
+		AccountID:         accountID,
+		ThresholdCents:    req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+		TTLSeconds:        req.TTLSeconds,
+	}
+	if policy.TTLSeconds == 0 {
+		policy.TTLSeconds = 24 * 60 * 60
+	}
+	if err := postgres.SetApprovalPolicy(h.DB, c.Request().Context(), &policy); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to set approval policy", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to set approval policy", nil))
+	}
+
+	return c.JSON(http.StatusOK, policy.ToApprovalPolicyFull())
+}
+
+func (h *Handler) DeleteApprovalPolicyHandler(c echo.Context) error {
+	accountID, ok, err := h.requireAccountRole(c, schemas.RoleOwner)
+	if !ok {
+		return err
+	}
+
+	if err := postgres.DeleteApprovalPolicy(h.DB, c.Request().Context(), accountID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "approval policy not set", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to delete approval policy", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to delete approval policy", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListPendingTransfersHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ListPendingTransfersRequest)
+	accountID, ok, err := h.requireAccountRole(c)
+	if !ok {
+		return err
+	}
+
+	transfers, err := postgres.ListPendingTransfers(h.DB, c.Request().Context(), accountID, req.Status, req.Size, (req.Page-1)*req.Size)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list pending transfers", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list pending transfers", nil))
+	}
+
+	resp := []schemas.PendingTransferFull{}
+	for _, t := range transfers {
+		resp = append(resp, t.ToPendingTransferFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetPendingTransferHandler(c echo.Context) error {
+	transferID, err := uuid.Parse(c.Param("transfer"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid transfer ID", nil))
+	}
+	accountID, ok, err := h.requireAccountRole(c)
+	if !ok {
+		return err
+	}
+
+	transfer, err := postgres.GetPendingTransfer(h.DB, c.Request().Context(), accountID, transferID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "pending transfer not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get pending transfer", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get pending transfer", nil))
+	}
+
+	return c.JSON(http.StatusOK, transfer.ToPendingTransferFull())
+}
+
+func (h *Handler) ApprovePendingTransferHandler(c echo.Context) error {
+	return h.decidePendingTransfer(c, schemas.DecisionApprove)
+}
+
+func (h *Handler) RejectPendingTransferHandler(c echo.Context) error {
+	return h.decidePendingTransfer(c, schemas.DecisionReject)
+}
+
+func (h *Handler) decidePendingTransfer(c echo.Context, decision schemas.ApprovalDecision) error {
+	req := c.Get("validatedBody").(*schemas.TransferDecisionRequest)
+	transferID, err := uuid.Parse(c.Param("transfer"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid transfer ID", nil))
+	}
+	accountID, ok, err := h.requireAccountRole(c, schemas.RoleOwner, schemas.RoleSpender)
+	if !ok {
+		return err
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	transfer, err := postgres.DecideTransfer(h.DB, c.Request().Context(), accountID, transferID, userID, decision, req.Comment)
+	if err != nil {
+		switch err {
+		case pgx.ErrNoRows:
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "pending transfer not found", nil))
+		case postgres.ErrTransferNotPending:
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "transfer is no longer pending", nil))
+		case postgres.ErrSelfApproval:
+			return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "initiator can not approve own transfer", nil))
+		case postgres.ErrDecisionChanged:
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "you have already made a different decision", nil))
+		case postgres.ErrCantPay:
+			return c.JSON(http.StatusPaymentRequired, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("PAYMENT_REQUIRED"), "approved, but the account has insufficient funds", nil))
+		case postgres.ErrSourceFrozen, postgres.ErrTargetFrozen, postgres.ErrDuplicateReference:
+			return h.transactionError(c, err)
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to decide pending transfer", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to decide pending transfer", nil))
+	}
+
+	return c.JSON(http.StatusOK, transfer.ToPendingTransferFull())
+}
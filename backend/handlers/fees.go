@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) QuoteFeeHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.FeeQuoteRequest)
+	if req.Type == "" {
+		req.Type = schemas.TransactionTransfer
+	}
+
+	from := c.Get("userID").(uuid.UUID)
+	if req.SourceID != nil {
+		from = *req.SourceID
+	}
+
+	quote, err := postgres.QuoteFee(h.DB, c.Request().Context(), req.Type, from, req.TargetID, req.Amount)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to quote fee", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to quote fee", nil))
+	}
+	return c.JSON(http.StatusOK, quote.ToFeeQuoteFull())
+}
+
+func (h *Handler) ListFeeRulesHandler(c echo.Context) error {
+	rules, err := postgres.ListFeeRules(h.DB, c.Request().Context())
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list fee rules", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list fee rules", nil))
+	}
+
+	resp := []schemas.FeeRuleFull{}
+	for _, r := range rules {
+		resp = append(resp, r.ToFeeRuleFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) CreateFeeRuleHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateFeeRuleRequest)
+
+	if req.MaxAmount != nil && *req.MaxAmount < req.MinAmount {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "max_amount must not be less than min_amount", nil))
+	}
+	if req.MaxFee != nil && *req.MaxFee < req.MinFee {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "max_fee must not be less than min_fee", nil))
+	}
+	if req.Model == schemas.FeeTiered {
+		// Tiers go in ascending up_to order, only the last one may be open-ended
+		var prev int64
+		for i, t := range req.Tiers {
+			if t.UpTo == nil {
+				if i != len(req.Tiers)-1 {
+					return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "only the last tier may omit up_to", nil))
+				}
+				continue
+			}
+			if *t.UpTo <= prev {
+				return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "tiers must be in ascending up_to order", nil))
+			}
+			prev = *t.UpTo
+		}
+	}
+
+	rule := postgres.FeeRule{
+		Name:            req.Name,
+		TransactionType: req.TransactionType,
+		AccountKind:     req.AccountKind,
+		MinAmountCents:  req.MinAmount,
+		MaxAmountCents:  req.MaxAmount,
+		Model:           req.Model,
+		FlatCents:       req.Flat,
+		PercentBps:      req.PercentBps,
+		Tiers:           req.Tiers,
+		MinFeeCents:     req.MinFee,
+		MaxFeeCents:     req.MaxFee,
+		Payer:           req.Payer,
+		Priority:        req.Priority,
+		CreatedBy:       c.Get("userID").(uuid.UUID),
+	}
+	if err := postgres.CreateFeeRule(h.DB, c.Request().Context(), &rule); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create fee rule", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create fee rule", nil))
+	}
+
+	return c.JSON(http.StatusCreated, rule.ToFeeRuleFull())
+}
+
+func (h *Handler) DeleteFeeRuleHandler(c echo.Context) error {
+	ruleID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid fee rule ID", nil))
+	}
+
+	if err := postgres.DeleteFeeRule(h.DB, c.Request().Context(), ruleID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "fee rule not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to delete fee rule", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to delete fee rule", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) FreezeAccountHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.FreezeAccountRequest)
+	accountID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid account ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "expires_at must be in the future", nil))
+	}
+
+	freeze := postgres.AccountFreeze{
+		AccountID: accountID,
+		Direction: req.Direction,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+		Creator:   userID,
+	}
+	if err := postgres.FreezeAccount(h.DB, c.Request().Context(), &freeze); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to freeze account", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to freeze account", nil))
+	}
+
+	return c.JSON(http.StatusCreated, freeze.ToAccountFreezeFull())
+}
+
+func (h *Handler) UnfreezeAccountHandler(c echo.Context) error {
+	accountID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid account ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if err := postgres.UnfreezeAccount(h.DB, c.Request().Context(), accountID, userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "account is not frozen", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to unfreeze account", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to unfreeze account", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListAccountFreezesHandler(c echo.Context) error {
+	accountID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid account ID", nil))
+	}
+
+	freezes, err := postgres.ListAccountFreezes(h.DB, c.Request().Context(), accountID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list account freezes", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list account freezes", nil))
+	}
+
+	resp := []schemas.AccountFreezeFull{}
+	for _, f := range freezes {
+		resp = append(resp, f.ToAccountFreezeFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -1,15 +1,19 @@
 package handlers
 
 import (
-	"github.com/lestrrat-go/jwx/v3/jwk"
-	gologger "github.com/nrf24l01/go-logger"
 	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/config"
+	"github.com/silaeder-labs/bank/backend/keycloak"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
 )
 
 type Handler struct {
-	DB     *pgkit.DB
-	Config *config.Config
-	Jwks   *jwk.Cache
-	Logger *gologger.Logger
+	DB          *pgkit.DB
+	Config      *config.Config
+	Verifier    *auth.Verifier
+	Logger      *logging.Logger
+	RateLimiter *ratelimit.Limiter
+	Directory   *keycloak.Client
 }
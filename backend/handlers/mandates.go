@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) CreateMandateHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateMandateRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.PayeeID == userID {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "payee must differ from payer", nil))
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "expires_at must be in the future", nil))
+	}
+	if ok, err := h.checkRecipient(c, req.PayeeID); !ok {
+		return err
+	}
+
+	mandate := postgres.Mandate{
+		Payer:       userID,
+		Payee:       req.PayeeID,
+		LimitCents:  req.Limit,
+		Period:      req.Period,
+		Description: strings.TrimSpace(req.Description),
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := postgres.CreateMandate(h.DB, c.Request().Context(), &mandate); err != nil {
+		if err == postgres.ErrMandateExists {
+			return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "mandate for this payee already exists", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create mandate", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create mandate", nil))
+	}
+
+	return c.JSON(http.StatusCreated, mandate.ToMandateFull())
+}
+
+func (h *Handler) ListMandatesHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ListMandatesRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	mandates, err := postgres.ListMandates(h.DB, c.Request().Context(), userID, req.Role, req.IncludeRevoked)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list mandates", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list mandates", nil))
+	}
+
+	resp := []schemas.MandateFull{}
+	for _, m := range mandates {
+		resp = append(resp, m.ToMandateFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) RevokeMandateHandler(c echo.Context) error {
+	mandateID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid mandate ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if err := postgres.RevokeMandate(h.DB, c.Request().Context(), mandateID, userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "mandate not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to revoke mandate", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to revoke mandate", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListMandateDebitsHandler(c echo.Context) error {
+	mandateID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid mandate ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if _, err := postgres.GetMandate(h.DB, c.Request().Context(), mandateID, userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "mandate not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get mandate", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get mandate", nil))
+	}
+
+	debits, err := postgres.ListMandateDebits(h.DB, c.Request().Context(), mandateID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list mandate debits", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list mandate debits", nil))
+	}
+
+	resp := []schemas.MandateDebitFull{}
+	for _, d := range debits {
+		resp = append(resp, d.ToMandateDebitFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+const defaultNotificationsLimit = 50
+
+// CreateMoneyRequestHandler lets a user ask another user for money without a service client.
+func (h *Handler) CreateMoneyRequestHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateMoneyRequestRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.FromID == userID {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "can't request money from yourself", nil))
+	}
+	if ok, err := h.checkRecipient(c, req.FromID); !ok {
+		return err
+	}
+
+	payment := postgres.Payment{
+		From:        &req.FromID,
+		To:          userID,
+		Creator:     userID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Category:    req.Category,
+		Status:      schemas.StatusPending,
+	}
+	if err := postgres.CreateMoneyRequest(h.DB, c.Request().Context(), &payment, h.Config.MoneyRequestConfig.MaxOpen); err != nil {
+		switch err {
+		case postgres.ErrMoneyRequestBlocked:
+			return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "user doesn't accept money requests from you", nil))
+		case postgres.ErrTooManyMoneyRequests:
+			return c.JSON(http.StatusTooManyRequests, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("TOO_MANY_REQUESTS"), "too many open money requests", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create money request", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create money request", nil))
+	}
+
+	h.autoPay(c, &payment)
+
+	return c.JSON(http.StatusCreated, payment.ToPaymentFull())
+}
+
+func (h *Handler) ListMoneyRequestBlocksHandler(c echo.Context) error {
+	userID := c.Get("userID").(uuid.UUID)
+
+	blocks, err := postgres.ListMoneyRequestBlocks(h.DB, c.Request().Context(), userID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list money request blocks", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list blocked users", nil))
+	}
+
+	resp := []schemas.MoneyRequestBlockFull{}
+	for _, b := range blocks {
+		resp = append(resp, b.ToMoneyRequestBlockFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) SetMoneyRequestBlockHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.SetMoneyRequestBlockRequest)
+	blockedID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid user ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if blockedID == userID {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "can't block yourself", nil))
+	}
+
+	block := postgres.MoneyRequestBlock{UserID: userID, BlockedID: blockedID, Mode: req.Mode}
+	if err := postgres.SetMoneyRequestBlock(h.DB, c.Request().Context(), &block); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to block user", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to block user", nil))
+	}
+
+	return c.JSON(http.StatusOK, block.ToMoneyRequestBlockFull())
+}
+
+func (h *Handler) DeleteMoneyRequestBlockHandler(c echo.Context) error {
+	blockedID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid user ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if err := postgres.DeleteMoneyRequestBlock(h.DB, c.Request().Context(), userID, blockedID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "user is not blocked", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to unblock user", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to unblock user", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListNotificationsHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ListNotificationsRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultNotificationsLimit
+	}
+
+	notifications, err := postgres.ListNotifications(h.DB, c.Request().Context(), userID, req.Unread, limit)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to list notifications", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to list notifications", nil))
+	}
+
+	resp := []schemas.NotificationFull{}
+	for _, n := range notifications {
+		resp = append(resp, n.ToNotificationFull())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) MarkNotificationsReadHandler(c echo.Context) error {
+	userID := c.Get("userID").(uuid.UUID)
+
+	if err := postgres.MarkNotificationsRead(h.DB, c.Request().Context(), userID); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to mark notifications read", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to mark notifications read", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
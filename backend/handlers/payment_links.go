@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+	"github.com/skip2/go-qrcode"
+)
+
+func (h *Handler) GetPaymentLinkHandler(c echo.Context) error {
+	link, expiresAt, ok, err := h.paymentLink(c)
+	if !ok {
+		return err
+	}
+	return c.JSON(http.StatusOK, schemas.PaymentLinkFull{
+		URL:       link,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *Handler) GetPaymentQRHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.PaymentQRRequest)
+	link, _, ok, err := h.paymentLink(c)
+	if !ok {
+		return err
+	}
+
+	size := req.Size
+	if size == 0 {
+		size = h.Config.PaymentLinkConfig.QRSize
+	}
+	qr, err := qrcode.New(link, qrcode.Medium)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeHTTP, "Failed to encode QR code", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to render QR code", nil))
+	}
+
+	if req.Format == "svg" {
+		return c.Blob(http.StatusOK, "image/svg+xml", qrSVG(qr, size))
+	}
+	png, err := qr.PNG(size)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeHTTP, "Failed to render QR code", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to render QR code", nil))
+	}
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// GetPaymentPreviewHandler is public, the link signature is the only access check.
+func (h *Handler) GetPaymentPreviewHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.PaymentPreviewRequest)
+	paymentID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid payment ID", nil))
+	}
+
+	cfg := h.Config.PaymentLinkConfig
+	if !cfg.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "payment links are not configured", nil))
+	}
+	if !auth.VerifyPaymentLink([]byte(cfg.Secret), paymentID, time.Unix(req.Expires, 0), req.Signature) {
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("INVALID_SIGNATURE"), "invalid or expired payment link", nil))
+	}
+
+	preview, err := postgres.GetPaymentPreview(h.DB, c.Request().Context(), paymentID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "payment not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get payment preview", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get payment", nil))
+	}
+
+	return c.JSON(http.StatusOK, preview.ToPaymentPreviewFull())
+}
+
+// paymentLink signs a link to the payment for its payer, payee or creator.
+// When ok is false the response is already written.
+func (h *Handler) paymentLink(c echo.Context) (string, time.Time, bool, error) {
+	paymentID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return "", time.Time{}, false, c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid payment ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	cfg := h.Config.PaymentLinkConfig
+	if !cfg.Enabled() {
+		return "", time.Time{}, false, c.JSON(http.StatusServiceUnavailable, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "payment links are not configured", nil))
+	}
+
+	if _, err := postgres.GetPaymentByID(h.DB, c.Request().Context(), paymentID, userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", time.Time{}, false, c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "payment not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get payment", err)
+		return "", time.Time{}, false, c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get payment", nil))
+	}
+
+	expiresAt := time.Now().Add(cfg.TTL).Truncate(time.Second)
+	query := url.Values{}
+	query.Set("exp", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("sig", auth.SignPaymentLink([]byte(cfg.Secret), paymentID, expiresAt))
+	return cfg.BaseURL + "/" + paymentID.String() + "?" + query.Encode(), expiresAt, true, nil
+}
+
+// qrSVG draws every dark module as a unit square, the viewBox scales them to size pixels.
+func qrSVG(qr *qrcode.QRCode, size int) []byte {
+	bitmap := qr.Bitmap()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	gologger "github.com/nrf24l01/go-logger"
 	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
 	"github.com/silaeder-labs/bank/backend/postgres"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
@@ -16,23 +18,60 @@ func (h *Handler) CreatePaymentHandler(c echo.Context) error {
 	req := c.Get("validatedBody").(*schemas.CreatePaymentRequest)
 	userID := c.Get("userID").(uuid.UUID)
 
+	if req.FromID != nil && (req.MaxUses != nil || req.MinAmount != nil || req.MaxAmount != nil) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "max_uses, min_amount and max_amount are only allowed for open requests", nil))
+	}
+	if req.Amount > 0 && (req.MinAmount != nil || req.MaxAmount != nil) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "min_amount and max_amount require an open amount", nil))
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MaxAmount < *req.MinAmount {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "max_amount must not be less than min_amount", nil))
+	}
+	if ok, err := h.checkRecipient(c, req.ToID); !ok {
+		return err
+	}
+
 	payment := postgres.Payment{
 		From:        req.FromID,
 		To:          req.ToID,
 		Amount:      req.Amount,
 		Description: req.Description,
+		Category:    req.Category,
+		Metadata:    req.Metadata,
 		Creator:     userID,
 		Status:      schemas.StatusPending,
+		MaxUses:     req.MaxUses,
+		MinAmount:   req.MinAmount,
+		MaxAmount:   req.MaxAmount,
 	}
 
 	if err := payment.Insert(h.DB, c.Request().Context()); err != nil {
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to create payment: "+err.Error(), c.Get("traceId").(string))
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create payment", err)
 		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create payment", nil))
 	}
 
+	h.autoPay(c, &payment)
+
 	return c.JSON(http.StatusCreated, payment.ToPaymentFull())
 }
 
+// autoPay pays a new payment under the payer's mandate. Without a mandate covering it, or when the
+// payer can't pay, the payment stays unpaid and is paid by hand.
+func (h *Handler) autoPay(c echo.Context, payment *postgres.Payment) {
+	if payment.IsOpen() {
+		return
+	}
+	paid, err := postgres.AutoPayPayment(h.DB, c.Request().Context(), payment.ID)
+	switch err {
+	case nil:
+		*payment = *paid
+	case postgres.ErrNoMandate, postgres.ErrMandateLimit, postgres.ErrCantPay, postgres.ErrSourceFrozen, postgres.ErrTargetFrozen:
+	default:
+		// The payment is created anyway and stays unpaid
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to auto-pay payment", err)
+	}
+}
+
 func (h *Handler) GetPaymentHandler(c echo.Context) error {
 	paymentIdStr := c.Param("uuid")
 	userID := c.Get("userID").(uuid.UUID)
@@ -44,7 +83,10 @@ func (h *Handler) GetPaymentHandler(c echo.Context) error {
 
 	payment, err := postgres.GetPaymentByID(h.DB, c.Request().Context(), paymentUUID, userID)
 	if err != nil {
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get payment: "+err.Error(), c.Get("traceId").(string))
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "payment not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get payment", err)
 		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get payment", nil))
 	}
 
@@ -52,29 +94,38 @@ func (h *Handler) GetPaymentHandler(c echo.Context) error {
 }
 
 func (h *Handler) RemovePaymentHandler(c echo.Context) error {
-	paymentIdStr := c.Param("uuid")
-	paymentUUID, err := uuid.Parse(paymentIdStr)
+	return h.changePaymentStatus(c, schemas.PaymentActionCancel, "")
+}
+
+func (h *Handler) DeclinePaymentHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.DeclinePaymentRequest)
+	return h.changePaymentStatus(c, schemas.PaymentActionDecline, strings.TrimSpace(req.Reason))
+}
+
+func (h *Handler) changePaymentStatus(c echo.Context, action schemas.PaymentAction, reason string) error {
+	paymentUUID, err := uuid.Parse(c.Param("uuid"))
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid payment ID", nil))
 	}
 	userID := c.Get("userID").(uuid.UUID)
 
-	payment, err := postgres.GetPaymentByID(h.DB, c.Request().Context(), paymentUUID, userID)
+	payment, err := postgres.ChangePaymentStatus(h.DB, c.Request().Context(), paymentUUID, userID, action, reason)
 	if err != nil {
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get payment: "+err.Error(), c.Get("traceId").(string))
-		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get payment", nil))
-	}
-
-	err = payment.ChangeStatus(h.DB, c.Request().Context(), schemas.StatusCancelled)
-	if err != nil {
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to change payment status: "+err.Error(), c.Get("traceId").(string))
+		if handled, err := h.paymentError(c, err); handled {
+			return err
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to change payment status", err)
 		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to change payment status", nil))
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	if action == schemas.PaymentActionCancel {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, payment.ToPaymentFull())
 }
 
 func (h *Handler) PayPaymentHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.PayPaymentRequest)
 	paymentIdStr := c.Param("uuid")
 	paymentUUID, err := uuid.Parse(paymentIdStr)
 	if err != nil {
@@ -82,25 +133,30 @@ func (h *Handler) PayPaymentHandler(c echo.Context) error {
 	}
 	userID := c.Get("userID").(uuid.UUID)
 
-	payment, err := postgres.GetPaymentByID(h.DB, c.Request().Context(), paymentUUID, userID)
+	payment, _, err := postgres.PayPayment(h.DB, c.Request().Context(), paymentUUID, userID, req.Amount)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "payment not found", nil))
+		if handled, err := h.paymentError(c, err); handled {
+			return err
 		}
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get payment: "+err.Error(), c.Get("traceId").(string))
-		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get payment", nil))
-	}
-
-	_, err = postgres.MakeTransaction(h.DB, c.Request().Context(), payment.From, payment.To, payment.Amount, payment.Description)
-	if err != nil {
-		if err == postgres.ErrCantPay {
-			return c.JSON(http.StatusPaymentRequired, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("PAYMENT_REQUIRED"), "insufficient funds", nil))
-		}
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to create transaction: "+err.Error(), c.Get("traceId").(string))
-		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create transaction", nil))
+		return h.transactionError(c, err)
 	}
 
-	payment.ChangeStatus(h.DB, c.Request().Context(), schemas.StatusCompleted)
-
 	return c.JSON(http.StatusCreated, payment.ToPaymentFull())
 }
+
+// paymentError writes the response for payment state and amount errors, handled is false for any other error.
+func (h *Handler) paymentError(c echo.Context, err error) (bool, error) {
+	switch err {
+	case pgx.ErrNoRows:
+		return true, c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "payment not found", nil))
+	case postgres.ErrPaymentTransition:
+		return true, c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "payment is already paid, cancelled or declined", nil))
+	case postgres.ErrPaymentForbidden:
+		return true, c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "action is not allowed for your role in the payment", nil))
+	case postgres.ErrOverpayment:
+		return true, c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "amount exceeds the remaining sum", nil))
+	case postgres.ErrAmountOutOfRange:
+		return true, c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "amount is outside of the allowed range", nil))
+	}
+	return false, nil
+}
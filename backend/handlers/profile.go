@@ -2,28 +2,132 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	gologger "github.com/nrf24l01/go-logger"
-	"github.com/nrf24l01/go-web-utils/echokit/schemas"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/config"
+	"github.com/silaeder-labs/bank/backend/logging"
 	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
 )
 
 func (h *Handler) GetBalanceHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.GetProfileRequest)
 	uid := c.Get("userID").(uuid.UUID)
 
-	balance, err := postgres.GetBalanceByUserID(h.DB, c.Request().Context(), uid)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return c.JSON(http.StatusNotFound, schemas.GenError(c, schemas.NOT_FOUND, "balance not found", nil))
+	names := req.Periods
+	if len(names) == 0 {
+		names = h.Config.ProfileConfig.StatsPeriods
+	}
+	now := time.Now()
+	periods := make([]postgres.StatsPeriod, 0, len(names))
+	for _, name := range names {
+		p := postgres.StatsPeriod{Name: name}
+		if d := config.StatsPeriods[name]; d > 0 {
+			p.Since = now.Add(-d)
 		}
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get balance: "+err.Error(), c.Get("traceId").(string))
-		return c.JSON(http.StatusInternalServerError, schemas.GenError(c, schemas.INTERNAL_SERVER_ERROR, "Failed to get balance", nil))
+		periods = append(periods, p)
+	}
+
+	stats, err := postgres.GetProfileStats(h.DB, c.Request().Context(), uid, periods)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get profile stats", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "Failed to get balance", nil))
 	}
 
-	balanceFull := balance.ToBalanceFull()
+	balanceFull := stats.ToBalanceFull()
+
+	freeze, err := postgres.GetActiveFreeze(h.DB, c.Request().Context(), uid)
+	if err != nil && err != pgx.ErrNoRows {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account freeze", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "Failed to get balance", nil))
+	}
+	if freeze != nil {
+		full := freeze.ToAccountFreezeFull()
+		full.CreatedBy = ""
+		balanceFull.Freeze = &full
+	}
 
 	return c.JSON(http.StatusOK, balanceFull)
 }
+
+func (h *Handler) GetBalanceAtHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.GetBalanceAtRequest)
+	uid := c.Get("userID").(uuid.UUID)
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	amount, err := postgres.GetBalanceAt(h.DB, c.Request().Context(), uid, at)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get balance at date", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "Failed to get balance", nil))
+	}
+
+	return c.JSON(http.StatusOK, schemas.BalanceAtFull{At: at.Format(time.RFC3339), Balance: amount})
+}
+
+func (h *Handler) GetBalanceHistoryHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.GetBalanceHistoryRequest)
+	uid := c.Get("userID").(uuid.UUID)
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.AddDate(0, 0, -30)
+	if req.From != nil {
+		from = *req.From
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "day"
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "from must be before to", nil))
+	}
+	if points := historyPoints(from, to, granularity); points > h.Config.ProfileConfig.HistoryMaxPoints {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "range is too long for this granularity", map[string]any{
+			"points":     points,
+			"max_points": h.Config.ProfileConfig.HistoryMaxPoints,
+		}))
+	}
+
+	opening, points, err := postgres.GetBalanceHistory(h.DB, c.Request().Context(), uid, from, to, granularity)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get balance history", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "Failed to get balance history", nil))
+	}
+
+	history := schemas.BalanceHistoryFull{
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		Granularity: granularity,
+		Opening:     opening,
+		Points:      make([]schemas.BalancePointFull, 0, len(points)),
+	}
+	for _, p := range points {
+		history.Points = append(history.Points, p.ToBalancePointFull())
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
+// historyPoints estimates the number of buckets so oversized ranges are rejected before querying.
+func historyPoints(from, to time.Time, granularity string) int {
+	days := int(to.Sub(from).Hours()/24) + 1
+	switch granularity {
+	case "week":
+		return days/7 + 1
+	case "month":
+		return days/28 + 1
+	default:
+		return days
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/keycloak"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// checkRecipient refuses to credit personal accounts of users unknown to or disabled in Keycloak.
+// Organization and system accounts are not Keycloak users and always pass. Without the admin
+// client every recipient passes. When ok is false the response is already written.
+func (h *Handler) checkRecipient(c echo.Context, id uuid.UUID) (bool, error) {
+	if h.Directory == nil {
+		return true, nil
+	}
+
+	kind, err := postgres.GetAccountKind(h.DB, c.Request().Context(), id)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account kind", err)
+		return false, c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to check recipient", nil))
+	}
+	if kind != schemas.AccountPersonal {
+		return true, nil
+	}
+
+	user, err := h.Directory.GetUser(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, keycloak.ErrUserNotFound) {
+			return false, c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.USER_NOT_FOUND, "recipient not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeAuth, "Failed to check recipient in Keycloak", err)
+		return false, c.JSON(http.StatusServiceUnavailable, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "recipient check is unavailable", nil))
+	}
+	if !user.Enabled {
+		return false, c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("RECIPIENT_DISABLED"), "recipient is disabled", nil))
+	}
+	return true, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) MoneySupplyReportHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.MoneySupplyRequest)
+
+	supply, err := postgres.GetMoneySupply(h.DB, c.Request().Context())
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get money supply", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to build report", nil))
+	}
+
+	full := supply.ToMoneySupplyFull()
+	if req.Format == schemas.ReportCSV {
+		return writeCSV(c, "money-supply",
+			[]string{"circulation", "issued", "issued_gross", "returned_gross", "accounts", "issuers"},
+			[][]string{{itoa(full.Circulation), itoa(full.Issued), itoa(full.IssuedGross), itoa(full.ReturnedGross), itoa(full.Accounts), itoa(full.Issuers)}})
+	}
+	return c.JSON(http.StatusOK, full)
+}
+
+func (h *Handler) DailyVolumeReportHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ReportRangeRequest)
+	from, to, ok, err := reportRange(c, req.From, req.To)
+	if !ok {
+		return err
+	}
+
+	volumes, err := postgres.GetDailyVolume(h.DB, c.Request().Context(), from, to)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get daily volume", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to build report", nil))
+	}
+
+	resp := make([]schemas.DailyVolumeFull, 0, len(volumes))
+	for _, v := range volumes {
+		resp = append(resp, v.ToDailyVolumeFull())
+	}
+	if req.Format == schemas.ReportCSV {
+		rows := make([][]string, 0, len(resp))
+		for _, v := range resp {
+			rows = append(rows, []string{v.Day, itoa(v.Volume), itoa(v.Transactions)})
+		}
+		return writeCSV(c, "daily-volume", []string{"day", "volume", "transactions"}, rows)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) TopAccountsReportHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.TopAccountsRequest)
+	from, to, ok, err := reportRange(c, req.From, req.To)
+	if !ok {
+		return err
+	}
+	by := req.By
+	if by == "" {
+		by = "balance"
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = 10
+	}
+
+	accounts, err := postgres.GetTopAccounts(h.DB, c.Request().Context(), by == "volume", from, to, limit)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get top accounts", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to build report", nil))
+	}
+
+	resp := make([]schemas.TopAccountFull, 0, len(accounts))
+	for _, a := range accounts {
+		resp = append(resp, a.ToTopAccountFull())
+	}
+	if req.Format == schemas.ReportCSV {
+		rows := make([][]string, 0, len(resp))
+		for _, a := range resp {
+			rows = append(rows, []string{a.AccountID, a.Name, itoa(a.Balance), itoa(a.Volume), itoa(a.Transactions)})
+		}
+		return writeCSV(c, "top-accounts-by-"+by, []string{"account_id", "name", "balance", "volume", "transactions"}, rows)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ActiveUsersReportHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.ActiveUsersRequest)
+	from, to, ok, err := reportRange(c, req.From, req.To)
+	if !ok {
+		return err
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "day"
+	}
+
+	active, err := postgres.GetActiveUsers(h.DB, c.Request().Context(), from, to, granularity)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get active users", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to build report", nil))
+	}
+
+	resp := make([]schemas.ActiveUsersFull, 0, len(active))
+	for _, a := range active {
+		resp = append(resp, a.ToActiveUsersFull())
+	}
+	if req.Format == schemas.ReportCSV {
+		rows := make([][]string, 0, len(resp))
+		for _, a := range resp {
+			rows = append(rows, []string{a.Start, itoa(a.ActiveUsers)})
+		}
+		return writeCSV(c, "active-users", []string{"start", "active_users"}, rows)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// reportRange defaults to the last 30 days and writes the response when the range is invalid.
+func reportRange(c echo.Context, fromParam, toParam *time.Time) (time.Time, time.Time, bool, error) {
+	to := time.Now()
+	if toParam != nil {
+		to = *toParam
+	}
+	from := to.AddDate(0, 0, -30)
+	if fromParam != nil {
+		from = *fromParam
+	}
+	if from.After(to) {
+		return from, to, false, c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "from must not be after to", nil))
+	}
+	return from, to, true, nil
+}
+
+func writeCSV(c echo.Context, name string, header []string, rows [][]string) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.csv"`, name, time.Now().UTC().Format("20060102")))
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvSafe(row[i])
+		}
+	}
+	return w.WriteAll(rows)
+}
+
+// csvSafe keeps spreadsheets from running user controlled values such as names as formulas,
+// numbers are left as they are.
+func csvSafe(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v
+	}
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// splitPercentTotal is 100% in basis points.
+const splitPercentTotal = 10000
+
+func (h *Handler) CreateSplitPaymentHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.CreateSplitPaymentRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	payers := make([]uuid.UUID, 0, len(req.Participants))
+	shares := make([]int64, 0, len(req.Participants))
+	seen := map[uuid.UUID]bool{}
+	var sharesSum int64
+	for _, p := range req.Participants {
+		if p.PayerID == req.ToID {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "payee can't be a participant", nil))
+		}
+		if seen[p.PayerID] {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "participants must be unique", nil))
+		}
+		seen[p.PayerID] = true
+
+		share := p.Share
+		if req.Mode == schemas.SplitEqual {
+			if share != 0 {
+				return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "shares are not allowed for equal splits", nil))
+			}
+			share = 1
+		} else if share == 0 {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "every participant needs a share", nil))
+		}
+		if req.Mode == schemas.SplitPercentage && share > splitPercentTotal {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "percentage shares must not exceed 10000", nil))
+		}
+		if share > math.MaxInt64-sharesSum {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "shares are too large", nil))
+		}
+		payers = append(payers, p.PayerID)
+		shares = append(shares, share)
+		sharesSum += share
+	}
+
+	amount := req.Amount
+	switch req.Mode {
+	case schemas.SplitPercentage:
+		if sharesSum != splitPercentTotal {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "percentage shares must add up to 10000", nil))
+		}
+	case schemas.SplitFixed:
+		if amount == 0 {
+			amount = sharesSum
+		}
+		if sharesSum != amount {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "fixed shares must add up to amount", nil))
+		}
+	}
+	if amount == 0 {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "amount is required", nil))
+	}
+
+	amounts := postgres.SplitShares(amount, shares)
+	for _, a := range amounts {
+		if a <= 0 {
+			return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "amount is too small to split", nil))
+		}
+	}
+	if ok, err := h.checkRecipient(c, req.ToID); !ok {
+		return err
+	}
+
+	split := postgres.SplitPayment{
+		Creator:     userID,
+		To:          req.ToID,
+		Amount:      amount,
+		Mode:        req.Mode,
+		Description: req.Description,
+		Category:    req.Category,
+	}
+	if err := postgres.CreateSplitPayment(h.DB, c.Request().Context(), &split, payers, amounts); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create split payment", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create split payment", nil))
+	}
+
+	for i := range split.Payments {
+		h.autoPay(c, &split.Payments[i])
+	}
+
+	created, err := postgres.GetSplitPayment(h.DB, c.Request().Context(), split.ID, userID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get split payment", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get split payment", nil))
+	}
+	return c.JSON(http.StatusCreated, created.ToSplitPaymentFull())
+}
+
+func (h *Handler) GetSplitPaymentHandler(c echo.Context) error {
+	splitID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid split ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	split, err := postgres.GetSplitPayment(h.DB, c.Request().Context(), splitID, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "split payment not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get split payment", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get split payment", nil))
+	}
+
+	return c.JSON(http.StatusOK, split.ToSplitPaymentFull())
+}
+
+func (h *Handler) CancelSplitPaymentHandler(c echo.Context) error {
+	splitID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid split ID", nil))
+	}
+	userID := c.Get("userID").(uuid.UUID)
+
+	if err := postgres.CancelSplitPayment(h.DB, c.Request().Context(), splitID, userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "split payment not found", nil))
+		}
+		if handled, err := h.paymentError(c, err); handled {
+			return err
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to cancel split payment", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to cancel split payment", nil))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
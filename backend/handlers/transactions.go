@@ -1,28 +1,89 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	gologger "github.com/nrf24l01/go-logger"
 	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
 	"github.com/silaeder-labs/bank/backend/postgres"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
 
 func (h *Handler) CreateTransactionHandler(c echo.Context) error {
 	req := c.Get("validatedBody").(*schemas.CreateTransactionRequest)
-	from := c.Get("userID").(uuid.UUID)
+	userID := c.Get("userID").(uuid.UUID)
 
-	transaction, err := postgres.MakeTransaction(h.DB, c.Request().Context(), from, req.TargetID, req.Amount, req.Comment)
-	if err != nil {
-		if err == postgres.ErrCantPay {
-			return c.JSON(http.StatusPaymentRequired, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("PAYMENT_REQUIRED"), "insufficient funds", nil))
+	from := userID
+	if req.SourceAccountID != nil && *req.SourceAccountID != userID {
+		from = *req.SourceAccountID
+		role, err := postgres.GetAccountRole(h.DB, c.Request().Context(), from, userID)
+		if err != nil && err != pgx.ErrNoRows {
+			h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get account role", err)
+			return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get account", nil))
+		}
+		if role != schemas.RoleOwner && role != schemas.RoleSpender {
+			return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "not allowed to spend from this account", nil))
+		}
+	}
+
+	to := req.TargetID
+	if to == uuid.Nil {
+		target, err := postgres.GetUserByUsername(h.DB, c.Request().Context(), req.TargetUsername)
+		if err != nil {
+			switch err {
+			case pgx.ErrNoRows:
+				return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.USER_NOT_FOUND, "recipient not found", nil))
+			case postgres.ErrAmbiguousUsername:
+				return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "username matches several users, use target_id", nil))
+			}
+			h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to resolve recipient", err)
+			return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to resolve recipient", nil))
+		}
+		to = target.ID
+	}
+	if ok, err := h.checkRecipient(c, to); !ok {
+		return err
+	}
+
+	draft := postgres.Transaction{
+		From:        from,
+		To:          to,
+		Initiator:   userID,
+		AmountCents: req.Amount,
+		Description: req.Comment,
+
+		Metadata:          req.Metadata,
+		ExternalReference: req.ExternalReference,
+	}
+
+	if from != userID {
+		policy, err := postgres.GetApprovalPolicy(h.DB, c.Request().Context(), from)
+		if err != nil && err != pgx.ErrNoRows {
+			h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get approval policy", err)
+			return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create transaction", nil))
+		}
+		if policy != nil && policy.Applies(req.Amount) {
+			pending, err := postgres.CreatePendingTransfer(h.DB, c.Request().Context(), draft, policy)
+			if err == postgres.ErrDuplicateReference {
+				return h.transactionError(c, err)
+			}
+			if err != nil {
+				h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create pending transfer", err)
+				return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create transaction", nil))
+			}
+			return c.JSON(http.StatusAccepted, pending.ToPendingTransferFull())
 		}
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to create transaction: "+err.Error(), c.Get("traceId").(string))
-		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create transaction", nil))
+	}
+
+	transaction, err := postgres.MakeTransaction(h.DB, c.Request().Context(), draft)
+	if err != nil {
+		return h.transactionError(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, transaction.ToTransactionFull())
@@ -34,9 +95,14 @@ func (h *Handler) GetTransactionsHandler(c echo.Context) error {
 
 	offset := (req.Page - 1) * req.Size
 
-	transactions, err := postgres.GetTransactionsByUserID(h.DB, c.Request().Context(), userID, req.Size, offset)
+	filter, ok, err := transactionFilter(c, req)
+	if !ok {
+		return err
+	}
+
+	transactions, err := postgres.GetTransactionsByUserID(h.DB, c.Request().Context(), userID, filter, req.Size, offset)
 	if err != nil {
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get transactions: "+err.Error(), c.Get("traceId").(string))
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get transactions", err)
 		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get transactions", nil))
 	}
 
@@ -61,7 +127,7 @@ func (h *Handler) GetTransactionByIDHandler(c echo.Context) error {
 		if err == pgx.ErrNoRows {
 			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "transaction not found", nil))
 		}
-		h.Logger.Log(gologger.LevelError, gologger.LogType("DB"), "Failed to get transaction: "+err.Error(), c.Get("traceId").(string))
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get transaction", err)
 		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get transaction", nil))
 	}
 
@@ -71,3 +137,67 @@ func (h *Handler) GetTransactionByIDHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, transaction.ToTransactionFull())
 }
+
+// transactionError writes the response for a failed MakeTransaction.
+func (h *Handler) transactionError(c echo.Context, err error) error {
+	switch err {
+	case postgres.ErrCantPay:
+		return c.JSON(http.StatusPaymentRequired, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("PAYMENT_REQUIRED"), "insufficient funds", nil))
+	case postgres.ErrSourceFrozen:
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("ACCOUNT_FROZEN"), "source account is frozen", nil))
+	case postgres.ErrTargetFrozen:
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("ACCOUNT_FROZEN"), "target account is frozen", nil))
+	case postgres.ErrDuplicateReference:
+		return c.JSON(http.StatusConflict, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("CONFLICT"), "external_reference is already used", nil))
+	}
+	h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to create transaction", err)
+	return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to create transaction", nil))
+}
+
+func (h *Handler) SetTransactionCategoryHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.SetTransactionCategoryRequest)
+	return h.setTransactionCategory(c, strings.TrimSpace(req.Category))
+}
+
+func (h *Handler) ResetTransactionCategoryHandler(c echo.Context) error {
+	return h.setTransactionCategory(c, "")
+}
+
+func (h *Handler) setTransactionCategory(c echo.Context, category string) error {
+	userID := c.Get("userID").(uuid.UUID)
+	transactionID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "invalid transaction ID", nil))
+	}
+
+	if err := postgres.SetTransactionCategory(h.DB, c.Request().Context(), transactionID, userID, category); err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.NOT_FOUND, "transaction not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to set transaction category", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to set category", nil))
+	}
+
+	transaction, err := postgres.GetTransactionByID(h.DB, c.Request().Context(), transactionID, userID)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get transaction", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get transaction", nil))
+	}
+	return c.JSON(http.StatusOK, transaction.ToTransactionFull())
+}
+
+// transactionFilter builds the list filter, metadata must be a JSON object matched by containment.
+func transactionFilter(c echo.Context, req *schemas.GetTransactionsRequest) (postgres.TransactionFilter, bool, error) {
+	filter := postgres.TransactionFilter{
+		Type:              req.Type,
+		ExternalReference: req.ExternalReference,
+	}
+	if req.Metadata != "" {
+		var metadata map[string]any
+		if err := json.Unmarshal([]byte(req.Metadata), &metadata); err != nil {
+			return filter, false, c.JSON(http.StatusBadRequest, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "metadata must be a JSON object", nil))
+		}
+		filter.Metadata = []byte(req.Metadata)
+	}
+	return filter, true, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) GetTreasuryHandler(c echo.Context) error {
+	treasury, err := postgres.GetTreasury(h.DB, c.Request().Context())
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get treasury", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get treasury", nil))
+	}
+	return c.JSON(http.StatusOK, treasury.ToTreasuryFull())
+}
+
+func (h *Handler) MintHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.TreasuryOperationRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.AccountID == postgres.TreasuryAccountID {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "cannot mint into the treasury", nil))
+	}
+	if ok, err := h.checkRecipient(c, req.AccountID); !ok {
+		return err
+	}
+
+	transaction, err := postgres.Mint(h.DB, c.Request().Context(), req.AccountID, userID, req.Amount, req.Comment)
+	if err != nil {
+		return h.transactionError(c, err)
+	}
+	return c.JSON(http.StatusCreated, transaction.ToTransactionFull())
+}
+
+func (h *Handler) BurnHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.TreasuryOperationRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	if req.AccountID == postgres.TreasuryAccountID {
+		return c.JSON(http.StatusUnprocessableEntity, echokitSchemas.GenError(c, echokitSchemas.BAD_REQUEST, "cannot burn from the treasury", nil))
+	}
+
+	transaction, err := postgres.Burn(h.DB, c.Request().Context(), req.AccountID, userID, req.Amount, req.Comment)
+	if err != nil {
+		return h.transactionError(c, err)
+	}
+	return c.JSON(http.StatusCreated, transaction.ToTransactionFull())
+}
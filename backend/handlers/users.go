@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func (h *Handler) SearchUsersHandler(c echo.Context) error {
+	req := c.Get("validatedQuery").(*schemas.SearchUsersRequest)
+	size := req.Size
+	if size == 0 {
+		size = 10
+	}
+
+	byEmail := auth.Admin().Allows(auth.GetPrincipal(c))
+	users, err := postgres.SearchUsers(h.DB, c.Request().Context(), req.Query, byEmail, size)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to search users", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to search users", nil))
+	}
+
+	resp := []schemas.UserPublic{}
+	for _, u := range users {
+		resp = append(resp, u.ToUserPublic())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetMeHandler(c echo.Context) error {
+	userID := c.Get("userID").(uuid.UUID)
+
+	user, err := postgres.GetUserByID(h.DB, c.Request().Context(), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return c.JSON(http.StatusNotFound, echokitSchemas.GenError(c, echokitSchemas.USER_NOT_FOUND, "user profile not found", nil))
+		}
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to get user", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to get user", nil))
+	}
+
+	return c.JSON(http.StatusOK, user.ToUserFull())
+}
+
+func (h *Handler) UpdatePrivacyHandler(c echo.Context) error {
+	req := c.Get("validatedBody").(*schemas.UpdatePrivacyRequest)
+	userID := c.Get("userID").(uuid.UUID)
+
+	user, err := postgres.SetUserDiscoverable(h.DB, c.Request().Context(), userID, *req.Discoverable)
+	if err != nil {
+		h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to update privacy", err)
+		return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to update privacy", nil))
+	}
+
+	return c.JSON(http.StatusOK, user.ToUserFull())
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/keycloak"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+const disabledUserReason = "user is disabled in Keycloak"
+
+// SyncDisabledUsers freezes accounts of users disabled in Keycloak and unfreezes them once re-enabled.
+// Freezes made by the job have a nil creator, so manual freezes are never touched.
+func SyncDisabledUsers(db *pgkit.DB, directory *keycloak.Client, logger *logging.Logger, interval time.Duration) Job {
+	return Job{
+		Name:     "sync-disabled-users",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			users, err := directory.ListDisabledUsers(ctx)
+			if err != nil {
+				return err
+			}
+			ids := make([]uuid.UUID, 0, len(users))
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+
+			frozen, lifted, err := postgres.SyncFreezes(db, ctx, ids, disabledUserReason, uuid.Nil)
+			if err != nil {
+				return err
+			}
+			if frozen > 0 || lifted > 0 {
+				logger.Log(gologger.LevelInfo, logging.TypeJobs, fmt.Sprintf("Disabled users synced: %d frozen, %d unfrozen", frozen, lifted), "")
+			}
+			return nil
+		},
+	}
+}
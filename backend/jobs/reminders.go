@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/config"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+func SendPaymentReminders(db *pgkit.DB, cfg *config.MoneyRequestConfig, logger *logging.Logger, interval time.Duration) Job {
+	return Job{
+		Name:     "send-payment-reminders",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			n, err := postgres.SendPaymentReminders(db, ctx, cfg.ReminderInterval, cfg.MaxReminders)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Log(gologger.LevelInfo, logging.TypeJobs, fmt.Sprintf("Sent %d payment reminders", n), "")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+// rollupLag keeps transactions that are still being committed out of the current refresh.
+const rollupLag = time.Minute
+
+func RefreshTransactionRollups(db *pgkit.DB, logger *logging.Logger, interval time.Duration) Job {
+	return Job{
+		Name:     "refresh-transaction-rollups",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			n, err := postgres.RefreshTransactionRollups(db, ctx, time.Now().Add(-rollupLag))
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Log(gologger.LevelDebug, logging.TypeJobs, fmt.Sprintf("Rolled up %d user days", n), "")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/silaeder-labs/bank/backend/logging"
+)
+
+// Job is a background task run every Interval, a zero interval disables it.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Runner struct {
+	logger *logging.Logger
+	jobs   []Job
+}
+
+func NewRunner(logger *logging.Logger) *Runner {
+	return &Runner{logger: logger}
+}
+
+func (r *Runner) Add(job Job) {
+	r.jobs = append(r.jobs, job)
+}
+
+// Start runs every job in its own goroutine until ctx is done.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		if job.Interval <= 0 {
+			r.logger.Log(gologger.LevelInfo, logging.TypeJobs, fmt.Sprintf("Job %s disabled", job.Name), "")
+			continue
+		}
+		go r.loop(ctx, job)
+	}
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, job Job) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.LogFields(gologger.LevelError, logging.TypeJobs, fmt.Sprintf("Job %s panicked", job.Name), logging.Fields{Error: fmt.Errorf("%v", rec)})
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		r.logger.LogFields(gologger.LevelError, logging.TypeJobs, fmt.Sprintf("Job %s failed", job.Name), logging.Fields{Error: err})
+	}
+}
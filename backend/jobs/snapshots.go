@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+// TakeBalanceSnapshots snapshots balances at the start of the current UTC day, later runs of the same day do nothing.
+// The day's snapshot waits rollupLag past midnight, so transactions inserted before midnight but still committing
+// are not skipped by every later snapshot.
+func TakeBalanceSnapshots(db *pgkit.DB, logger *logging.Logger, interval time.Duration) Job {
+	return Job{
+		Name:     "take-balance-snapshots",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			cutoff := time.Now().UTC().Add(-rollupLag).Truncate(24 * time.Hour)
+			n, err := postgres.TakeBalanceSnapshots(db, ctx, cutoff)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Log(gologger.LevelInfo, logging.TypeJobs, fmt.Sprintf("Took %d balance snapshots", n), "")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+func ExpirePendingTransfers(db *pgkit.DB, logger *logging.Logger, interval time.Duration) Job {
+	return Job{
+		Name:     "expire-pending-transfers",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			n, err := postgres.ExpirePendingTransfers(db, ctx)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Log(gologger.LevelInfo, logging.TypeJobs, fmt.Sprintf("Expired %d pending transfers", n), "")
+			}
+			return nil
+		},
+	}
+}
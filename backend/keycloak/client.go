@@ -0,0 +1,220 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+var ErrUserNotFound = errors.New("keycloak user not found")
+
+const (
+	disabledPageSize  = 100
+	negativeCacheTTL  = time.Minute
+	tokenExpiryLeeway = 30 * time.Second
+)
+
+type User struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Enabled  bool      `json:"enabled"`
+}
+
+// Client talks to the Keycloak Admin REST API with a client-credentials service account.
+// The account needs the view-users role of realm-management.
+type Client struct {
+	baseURL      string
+	realm        string
+	clientID     string
+	clientSecret string
+	http         *http.Client
+	cacheTTL     time.Duration
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	users       map[uuid.UUID]cachedUser
+}
+
+type cachedUser struct {
+	user    *User
+	expires time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func New(baseURL, realm, clientID, clientSecret string, httpClient *http.Client, cacheTTL time.Duration) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		realm:        realm,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		http:         httpClient,
+		cacheTTL:     cacheTTL,
+		users:        map[uuid.UUID]cachedUser{},
+	}
+}
+
+// NewFromConfig returns nil when the admin API is disabled.
+func NewFromConfig(cfg *config.KeyCloakConfig) *Client {
+	if !cfg.AdminEnabled {
+		return nil
+	}
+	return New(cfg.AuthServer, cfg.Realm, cfg.AdminClientID, cfg.AdminClientSecret, &http.Client{Timeout: cfg.AdminTimeout}, cfg.UserCacheTTL)
+}
+
+// GetUser returns the user, cached for the configured TTL. Missing users are cached
+// for a shorter time so freshly registered users become visible quickly.
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	c.mu.Lock()
+	cached, ok := c.users[id]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		if cached.user == nil {
+			return nil, ErrUserNotFound
+		}
+		return cached.user, nil
+	}
+
+	var user User
+	err := c.get(ctx, "/users/"+id.String(), nil, &user)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	entry := cachedUser{expires: time.Now().Add(c.cacheTTL)}
+	if err == nil {
+		entry.user = &user
+	} else if negativeCacheTTL < c.cacheTTL {
+		entry.expires = time.Now().Add(negativeCacheTTL)
+	}
+	c.mu.Lock()
+	c.users[id] = entry
+	c.mu.Unlock()
+
+	if entry.user == nil {
+		return nil, ErrUserNotFound
+	}
+	return entry.user, nil
+}
+
+// ListDisabledUsers pages through every disabled user of the realm.
+func (c *Client) ListDisabledUsers(ctx context.Context) ([]User, error) {
+	var all []User
+	for first := 0; ; first += disabledPageSize {
+		var page []User
+		query := url.Values{
+			"enabled":             {"false"},
+			"briefRepresentation": {"true"},
+			"first":               {strconv.Itoa(first)},
+			"max":                 {strconv.Itoa(disabledPageSize)},
+		}
+		if err := c.get(ctx, "/users", query, &page); err != nil {
+			return nil, err
+		}
+		for _, u := range page {
+			// Older Keycloak versions ignore the enabled filter
+			if !u.Enabled {
+				all = append(all, u)
+			}
+		}
+		if len(page) < disabledPageSize {
+			return all, nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s/admin/realms/%s%s", c.baseURL, url.PathEscape(c.realm), path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusNotFound:
+		return ErrUserNotFound
+	case http.StatusUnauthorized:
+		// Token revoked or rotated, fetch a new one next time
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	return fmt.Errorf("keycloak admin api %s: unexpected status %d", path, resp.StatusCode)
+}
+
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+	}
+	u := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", c.baseURL, url.PathEscape(c.realm))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("keycloak token endpoint: unexpected status %d", resp.StatusCode)
+	}
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	if tr.AccessToken == "" {
+		return "", errors.New("keycloak token endpoint: empty access token")
+	}
+
+	c.token = tr.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	return c.token, nil
+}
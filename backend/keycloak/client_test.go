@@ -0,0 +1,184 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testRealm  = "bank"
+	testToken  = "admin-token"
+	testClient = "bank-admin"
+	testSecret = "s3cret"
+)
+
+type stubKeycloak struct {
+	users       map[uuid.UUID]User
+	tokenCalls  atomic.Int32
+	userCalls   atomic.Int32
+	tokenExpiry int64
+}
+
+func newStub(t *testing.T, users ...User) (*stubKeycloak, *httptest.Server) {
+	t.Helper()
+	stub := &stubKeycloak{users: map[uuid.UUID]User{}, tokenExpiry: 300}
+	for _, u := range users {
+		stub.users[u.ID] = u
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /realms/"+testRealm+"/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		stub.tokenCalls.Add(1)
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" ||
+			r.PostForm.Get("client_id") != testClient || r.PostForm.Get("client_secret") != testSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(tokenResponse{AccessToken: testToken, ExpiresIn: stub.tokenExpiry})
+	})
+	mux.HandleFunc("GET /admin/realms/"+testRealm+"/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		stub.userCalls.Add(1)
+		id, err := uuid.Parse(r.PathValue("id"))
+		u, ok := stub.users[id]
+		if err != nil || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(u)
+	})
+	mux.HandleFunc("GET /admin/realms/"+testRealm+"/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+		max, _ := strconv.Atoi(r.URL.Query().Get("max"))
+		var disabled []User
+		for i := 0; i < 150; i++ {
+			disabled = append(disabled, User{ID: uuid.New(), Username: "user" + strconv.Itoa(i)})
+		}
+		end := min(first+max, len(disabled))
+		if first > len(disabled) {
+			first = len(disabled)
+		}
+		_ = json.NewEncoder(w).Encode(disabled[first:end])
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func TestGetUser(t *testing.T) {
+	enabled := User{ID: uuid.New(), Username: "alice", Enabled: true}
+	disabled := User{ID: uuid.New(), Username: "bob", Enabled: false}
+	stub, srv := newStub(t, enabled, disabled)
+	c := New(srv.URL, testRealm, testClient, testSecret, srv.Client(), time.Minute)
+
+	u, err := c.GetUser(context.Background(), enabled.ID)
+	if err != nil {
+		t.Fatalf("get enabled user: %v", err)
+	}
+	if u.Username != "alice" || !u.Enabled {
+		t.Fatalf("unexpected user %+v", u)
+	}
+
+	u, err = c.GetUser(context.Background(), disabled.ID)
+	if err != nil {
+		t.Fatalf("get disabled user: %v", err)
+	}
+	if u.Enabled {
+		t.Fatalf("expected disabled user")
+	}
+
+	if _, err := c.GetUser(context.Background(), uuid.New()); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+
+	if got := stub.tokenCalls.Load(); got != 1 {
+		t.Fatalf("token should be reused, fetched %d times", got)
+	}
+}
+
+func TestGetUserCache(t *testing.T) {
+	user := User{ID: uuid.New(), Username: "alice", Enabled: true}
+	missing := uuid.New()
+	stub, srv := newStub(t, user)
+	c := New(srv.URL, testRealm, testClient, testSecret, srv.Client(), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetUser(context.Background(), user.ID); err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+		if _, err := c.GetUser(context.Background(), missing); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("expected ErrUserNotFound, got %v", err)
+		}
+	}
+	if got := stub.userCalls.Load(); got != 2 {
+		t.Fatalf("expected 2 upstream lookups, got %d", got)
+	}
+
+	c = New(srv.URL, testRealm, testClient, testSecret, srv.Client(), 0)
+	stub.userCalls.Store(0)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetUser(context.Background(), user.ID); err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+	}
+	if got := stub.userCalls.Load(); got != 2 {
+		t.Fatalf("zero TTL should not cache, got %d lookups", got)
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	user := User{ID: uuid.New(), Enabled: true}
+	stub, srv := newStub(t, user)
+	// expires_in below the leeway, so every call needs a new token
+	stub.tokenExpiry = 1
+	c := New(srv.URL, testRealm, testClient, testSecret, srv.Client(), 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetUser(context.Background(), user.ID); err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+	}
+	if got := stub.tokenCalls.Load(); got != 2 {
+		t.Fatalf("expected token to be refetched, got %d calls", got)
+	}
+}
+
+func TestBadCredentials(t *testing.T) {
+	_, srv := newStub(t)
+	c := New(srv.URL, testRealm, testClient, "wrong", srv.Client(), time.Minute)
+
+	_, err := c.GetUser(context.Background(), uuid.New())
+	if err == nil || errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected token error, got %v", err)
+	}
+}
+
+func TestListDisabledUsers(t *testing.T) {
+	_, srv := newStub(t)
+	c := New(srv.URL, testRealm, testClient, testSecret, srv.Client(), time.Minute)
+
+	users, err := c.ListDisabledUsers(context.Background())
+	if err != nil {
+		t.Fatalf("list disabled users: %v", err)
+	}
+	if len(users) != 150 {
+		t.Fatalf("expected 150 users across pages, got %d", len(users))
+	}
+}
@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+)
+
+func FieldsFromContext(c echo.Context) Fields {
+	f := Fields{Route: c.Path()}
+	if v, ok := c.Get("traceId").(string); ok {
+		f.TraceID = v
+	}
+	if v := c.Get("userID"); v != nil {
+		f.UserID = fmt.Sprint(v)
+	}
+	return f
+}
+
+// LogRequest logs a message bound to the current request, attaching trace, user and route.
+func (l *Logger) LogRequest(c echo.Context, level gologger.Level, lt gologger.LogType, message string, err error) {
+	f := FieldsFromContext(c)
+	f.Error = err
+	l.LogFields(level, lt, message, f)
+}
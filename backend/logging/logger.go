@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+const (
+	TypeHTTP  gologger.LogType = "HTTP"
+	TypeDB    gologger.LogType = "DB"
+	TypeAuth  gologger.LogType = "AUTH"
+	TypeCLI   gologger.LogType = "CLI"
+	TypeSetup gologger.LogType = "SETUP"
+	TypeJobs  gologger.LogType = "JOBS"
+)
+
+type Fields struct {
+	TraceID string
+	UserID  string
+	Route   string
+	Error   error
+	Extra   map[string]any
+}
+
+type Logger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	service  string
+	format   Format
+	text     *gologger.Logger
+	level    gologger.Level
+	levels   map[gologger.LogType]gologger.Level
+	redactor *Redactor
+}
+
+type jsonEntry struct {
+	Time    string         `json:"time"`
+	Level   string         `json:"level"`
+	Type    string         `json:"type"`
+	Service string         `json:"service"`
+	Message string         `json:"message"`
+	TraceID string         `json:"trace_id,omitempty"`
+	UserID  string         `json:"user_id,omitempty"`
+	Route   string         `json:"route,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+func New(out io.Writer, service string, cfg *config.LoggingConfig, opts ...gologger.LoggerOption) (*Logger, error) {
+	format := Format(strings.ToLower(cfg.Format))
+	switch format {
+	case "":
+		format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	level := gologger.LevelDebug
+	if cfg.Level != "" {
+		l, err := ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		level = l
+	}
+
+	levels := map[gologger.LogType]gologger.Level{}
+	for t, v := range cfg.TypeLevels {
+		l, err := ParseLevel(v)
+		if err != nil {
+			return nil, fmt.Errorf("log level for %s: %w", t, err)
+		}
+		levels[gologger.LogType(strings.ToUpper(t))] = l
+	}
+
+	return &Logger{
+		out:      out,
+		service:  service,
+		format:   format,
+		text:     gologger.NewLogger(out, service, opts...),
+		level:    level,
+		levels:   levels,
+		redactor: NewRedactor(cfg.RedactFields),
+	}, nil
+}
+
+func ParseLevel(s string) (gologger.Level, error) {
+	for l := gologger.LevelDebug; l <= gologger.LevelFatal; l++ {
+		if strings.EqualFold(l.String(), s) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l *Logger) Redactor() *Redactor {
+	return l.redactor
+}
+
+func (l *Logger) Enabled(level gologger.Level, lt gologger.LogType) bool {
+	min, ok := l.levels[lt]
+	if !ok {
+		min = l.level
+	}
+	return level >= min
+}
+
+// Log keeps the gologger signature so plain messages can be written without building Fields.
+func (l *Logger) Log(level gologger.Level, lt gologger.LogType, message, traceID string) {
+	l.LogFields(level, lt, message, Fields{TraceID: traceID})
+}
+
+func (l *Logger) LogFields(level gologger.Level, lt gologger.LogType, message string, f Fields) {
+	if !l.Enabled(level, lt) {
+		return
+	}
+
+	message = l.redactor.String(message)
+	errText := ""
+	if f.Error != nil {
+		errText = l.redactor.String(f.Error.Error())
+	}
+	extra := l.redactor.Fields(f.Extra)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		entry := jsonEntry{
+			Time:    time.Now().UTC().Format(time.RFC3339Nano),
+			Level:   level.String(),
+			Type:    lt.String(),
+			Service: l.service,
+			Message: message,
+			TraceID: f.TraceID,
+			UserID:  f.UserID,
+			Route:   f.Route,
+			Error:   errText,
+			Fields:  extra,
+		}
+		b, err := json.Marshal(entry)
+		if err != nil {
+			fmt.Fprintf(l.out, `{"level":"ERROR","type":"SETUP","message":"failed to encode log entry: %s"}`+"\n", err)
+			return
+		}
+		l.out.Write(append(b, '\n'))
+		return
+	}
+
+	parts := []string{message}
+	if f.UserID != "" {
+		parts = append(parts, "user_id="+f.UserID)
+	}
+	if f.Route != "" {
+		parts = append(parts, "route="+f.Route)
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, extra[k]))
+	}
+	if errText != "" {
+		parts = append(parts, "error="+errText)
+	}
+	l.text.Log(level, lt, strings.Join(parts, " "), f.TraceID)
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var defaultSensitiveKeys = []string{
+	"authorization",
+	"bank-api-key",
+	"api_key",
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"client_secret",
+	"secret",
+	"password",
+	"cookie",
+	"set-cookie",
+	// Payment link signatures are credentials for the public preview
+	"sig",
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// Redactor strips tokens and configured sensitive fields before they reach the log output.
+type Redactor struct {
+	keys       map[string]struct{}
+	keyPattern *regexp.Regexp
+}
+
+func NewRedactor(extraKeys []string) *Redactor {
+	r := &Redactor{keys: map[string]struct{}{}}
+
+	var quoted []string
+	for _, k := range append(append([]string{}, defaultSensitiveKeys...), extraKeys...) {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		if _, ok := r.keys[k]; ok {
+			continue
+		}
+		r.keys[k] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+
+	// Matches key=value, key: value and "key":"value" forms, a value may carry an auth scheme like "Bearer x"
+	r.keyPattern = regexp.MustCompile(`(?i)(["']?\b(?:` + strings.Join(quoted, "|") + `)\b["']?\s*[:=]\s*["']?)((?:bearer\s+)?[^\s"'&,;}]+)`)
+	return r
+}
+
+func (r *Redactor) IsSensitive(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = r.keyPattern.ReplaceAllString(s, "${1}"+redacted)
+	return s
+}
+
+func (r *Redactor) Fields(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if r.IsSensitive(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = r.value(v)
+	}
+	return out
+}
+
+func (r *Redactor) value(v any) any {
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case error:
+		return r.String(val.Error())
+	case map[string]any:
+		return r.Fields(val)
+	case map[string]string:
+		out := make(map[string]any, len(val))
+		for k, s := range val {
+			out[k] = s
+		}
+		return r.Fields(out)
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = r.String(s)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -15,8 +15,14 @@ import (
 	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/config"
 	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/jobs"
+	"github.com/silaeder-labs/bank/backend/keycloak"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/middleware"
 	"github.com/silaeder-labs/bank/backend/postgres"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
 	"github.com/silaeder-labs/bank/backend/routes"
+	"github.com/silaeder-labs/bank/backend/schemas"
 
 	echoMw "github.com/labstack/echo/v4/middleware"
 	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
@@ -33,107 +39,149 @@ func main() {
 
 	ctx := context.Background()
 
+	envErr := godotenv.Load(".env")
+
 	// Logger create
-	logger := gologger.NewLogger(os.Stdout, "bank",
+	logger, err := logging.New(os.Stdout, "bank", config.LoadLoggingConfigFromEnv(),
 		gologger.WithTypeColors(map[gologger.LogType]string{
-			gologger.LogType("HTTP"):  gologger.BgCyan,
-			gologger.LogType("DB"):    gologger.BgGreen,
-			gologger.LogType("SETUP"): gologger.BgRed,
-			gologger.LogType("AUTH"):  gologger.BgMagenta,
-			gologger.LogType("CLI"): gologger.BgCyan,
+			logging.TypeHTTP:  gologger.BgCyan,
+			logging.TypeDB:    gologger.BgGreen,
+			logging.TypeSetup: gologger.BgRed,
+			logging.TypeAuth:  gologger.BgMagenta,
+			logging.TypeCLI:   gologger.BgCyan,
+			logging.TypeJobs:  gologger.BgYellow,
 		}),
 	)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
 	log.Printf("Logger initialized")
 
-	err := godotenv.Load(".env")
-	if err != nil {
-		logger.Log(gologger.LevelWarn, gologger.LogType("SETUP"), fmt.Sprintf("Failed to load .env file: %v", err), "")
+	if envErr != nil {
+		logger.LogFields(gologger.LevelWarn, logging.TypeSetup, "Failed to load .env file", logging.Fields{Error: envErr})
 	} else {
-		logger.Log(gologger.LevelSuccess, gologger.LogType("SETUP"), ".env file loaded", "")
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, ".env file loaded", "")
 	}
 
 	// Configuration initialization
 	config, err := config.BuildConfigFromEnv()
 	if err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("SETUP"), fmt.Sprintf("Failed to build config: %v", err), "")
+		logger.LogFields(gologger.LevelFatal, logging.TypeSetup, "Failed to build config", logging.Fields{Error: err})
 		return
 	} else {
-		logger.Log(gologger.LevelSuccess, gologger.LogType("SETUP"), "Configuration loaded", "")
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Configuration loaded", "")
 	}
 
 	// Data sources initialization
 	db, err := pgkit.NewDB(ctx, config.PGConfig)
 	if err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("SETUP"), fmt.Sprintf("Failed to connect to postgres: %v", err), "")
+		logger.LogFields(gologger.LevelFatal, logging.TypeSetup, "Failed to connect to postgres", logging.Fields{Error: err})
 		return
 	} else {
-		logger.Log(gologger.LevelSuccess, gologger.LogType("SETUP"), "Connected to Postgres database", "")
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Connected to Postgres database", "")
 	}
 	err = pgkit.RunMigrations(db.SQL, config.PGConfig)
 	if err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("SETUP"), fmt.Sprintf("Failed to run migrations: %v", err), "")
+		logger.LogFields(gologger.LevelFatal, logging.TypeSetup, "Failed to run migrations", logging.Fields{Error: err})
 		return
 	} else {
-		logger.Log(gologger.LevelSuccess, gologger.LogType("SETUP"), "Migrations ran successfully", "")
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Migrations ran successfully", "")
 	}
 
-	// Keycloak key verifier init
-	jwks, err := auth.RegisterJwks(config.KeyCloakConfig, logger, &ctx)
+	// Token verifier init
+	verifier, err := auth.RegisterProviders(config.OIDCConfig, logger, &ctx)
 	if err != nil {
-		logger.Log(gologger.LevelFatal, gologger.LogType("SETUP"), fmt.Sprintf("Failed to register JWKS: %v", err), "")
+		logger.LogFields(gologger.LevelFatal, logging.TypeSetup, "Failed to register identity providers", logging.Fields{Error: err})
 		return
 	} else {
-		logger.Log(gologger.LevelSuccess, gologger.LogType("SETUP"), "JWKS registered", "")
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Identity providers registered", "")
 	}
 
 	// Unlimited balance CLI commands
 	if *grantUnlimitedFlag != "" {
 		targetID, err := uuid.Parse(*grantUnlimitedFlag)
 		if err != nil {
-			logger.Log(gologger.LevelFatal, gologger.LogType("CLI"), fmt.Sprintf("invalid grant-unlimited uuid: %v", err), "")
+			logger.LogFields(gologger.LevelFatal, logging.TypeCLI, "invalid grant-unlimited uuid", logging.Fields{Error: err})
 			return
 		}
 		if err := postgres.GrantUnlimitedBalance(db, ctx, targetID); err != nil {
-			logger.Log(gologger.LevelFatal, gologger.LogType("DB"), fmt.Sprintf("failed to grant unlimited balance: %v", err), "")
+			logger.LogFields(gologger.LevelFatal, logging.TypeDB, "failed to grant unlimited balance", logging.Fields{Error: err})
 			return
 		}
-		logger.Log(gologger.LevelSuccess, gologger.LogType("CLI"), fmt.Sprintf("unlimited balance granted to %s", targetID.String()), "")
+		logger.Log(gologger.LevelSuccess, logging.TypeCLI, fmt.Sprintf("unlimited balance granted to %s", targetID.String()), "")
 		return
 	}
 	if *revokeUnlimitedFlag != "" {
 		targetID, err := uuid.Parse(*revokeUnlimitedFlag)
 		if err != nil {
-			logger.Log(gologger.LevelFatal, gologger.LogType("CLI"), fmt.Sprintf("invalid revoke-unlimited uuid: %v", err), "")
+			logger.LogFields(gologger.LevelFatal, logging.TypeCLI, "invalid revoke-unlimited uuid", logging.Fields{Error: err})
 			return
 		}
 		if err := postgres.RevokeUnlimitedBalance(db, ctx, targetID); err != nil {
-			logger.Log(gologger.LevelFatal, gologger.LogType("DB"), fmt.Sprintf("failed to revoke unlimited balance: %v", err), "")
+			logger.LogFields(gologger.LevelFatal, logging.TypeDB, "failed to revoke unlimited balance", logging.Fields{Error: err})
 			return
 		}
-		logger.Log(gologger.LevelSuccess, gologger.LogType("CLI"), fmt.Sprintf("unlimited balance revoked for %s", targetID.String()), "")
+		logger.Log(gologger.LevelSuccess, logging.TypeCLI, fmt.Sprintf("unlimited balance revoked for %s", targetID.String()), "")
 		return
 	}
 
+	// Rate limiter init
+	var limiter *ratelimit.Limiter
+	if config.RateLimitConfig.Enabled {
+		limiter, err = ratelimit.NewFromConfig(config.RateLimitConfig, db)
+		if err != nil {
+			logger.LogFields(gologger.LevelFatal, logging.TypeSetup, "Failed to create rate limiter", logging.Fields{Error: err})
+			return
+		}
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, fmt.Sprintf("Rate limiter enabled with %s backend", config.RateLimitConfig.Backend), "")
+	}
+
+	// Keycloak admin client for recipient checks
+	directory := keycloak.NewFromConfig(config.KeyCloakConfig)
+	if directory != nil {
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Keycloak admin client enabled", "")
+	}
+
+	// Background jobs
+	if config.JobsConfig.Enabled {
+		runner := jobs.NewRunner(logger)
+		runner.Add(jobs.ExpirePendingTransfers(db, logger, config.JobsConfig.ExpireTransfersInterval))
+		runner.Add(jobs.TakeBalanceSnapshots(db, logger, config.JobsConfig.BalanceSnapshotInterval))
+		runner.Add(jobs.RefreshTransactionRollups(db, logger, config.JobsConfig.RollupInterval))
+		runner.Add(jobs.SendPaymentReminders(db, config.MoneyRequestConfig, logger, config.JobsConfig.RemindersInterval))
+		if directory != nil {
+			runner.Add(jobs.SyncDisabledUsers(db, directory, logger, config.JobsConfig.SyncDisabledInterval))
+		}
+		runner.Start(ctx)
+		logger.Log(gologger.LevelSuccess, logging.TypeSetup, "Background jobs started", "")
+	}
+
 	// Create echo object
 	e := echo.New()
 
 	// Register custom validator
 	v := validator.New()
+	if err := v.RegisterValidation("metadata", schemas.ValidateMetadata); err != nil {
+		log.Fatalf("Failed to register metadata validator: %v", err)
+	}
 	e.Validator = &echokitMw.CustomValidator{Validator: v}
 
 	// Echo Configs
+	// Trust X-Forwarded-For only from private networks so public rate limits can't be dodged by spoofing it
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
 	e.Use(echoMw.Recover())
 	e.Use(echoMw.RemoveTrailingSlash())
 	e.Use(echokitMw.TraceMiddleware())
 
-	e.Use(echokitMw.RequestLogger(logger))
+	e.Use(middleware.RequestLogger(logger))
 
 	// Cors
 	log.Printf("Setting allowed origin to: %s", config.WebAppConfig.AllowOrigin)
 	e.Use(echoMw.CORSWithConfig(echoMw.CORSConfig{
 		AllowOrigins:     []string{config.WebAppConfig.AllowOrigin},
-		AllowMethods:     []string{echo.GET, echo.POST, echo.OPTIONS, echo.DELETE},
+		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.OPTIONS, echo.DELETE},
 		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		ExposeHeaders:    []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", echo.HeaderRetryAfter},
 		AllowCredentials: true,
 	}))
 
@@ -146,7 +194,7 @@ func main() {
 	})
 
 	// Register routes
-	handler := &handlers.Handler{DB: db, Config: config, Logger: logger, Jwks: jwks}
+	handler := &handlers.Handler{DB: db, Config: config, Logger: logger, Verifier: verifier, RateLimiter: limiter, Directory: directory}
 	routes.RegisterRoutes(api, handler)
 
 	// Start server
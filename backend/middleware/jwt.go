@@ -1,27 +1,40 @@
 package middleware
 
 import (
-	"context"
-	"crypto/ecdsa"
-	"crypto/rsa"
-	"fmt"
+	"errors"
 	"net/http"
-	"slices"
-	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
-	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/jackc/pgx/v5"
 	gologger "github.com/nrf24l01/go-logger"
 	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/postgres"
 
 	"github.com/labstack/echo/v4"
 )
 
-func JWTMiddleware(h *handlers.Handler, payment_create_required bool) echo.MiddlewareFunc {
+// JWTMiddleware authenticates the request and checks the route requirement, nil means any valid token.
+func JWTMiddleware(h *handlers.Handler, required auth.Requirement) echo.MiddlewareFunc {
+	if required == nil {
+		required = auth.Authenticated()
+	}
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// Bank-native API keys for internal services
+			if apiKey := c.Request().Header.Get(auth.ApiKeyHeader); apiKey != "" {
+				principal, err := authenticateApiKey(h, c, apiKey)
+				if err != nil {
+					if errors.Is(err, pgx.ErrNoRows) {
+						return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "invalid api key", nil))
+					}
+					h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to check api key", err)
+					return c.JSON(http.StatusInternalServerError, echokitSchemas.GenError(c, echokitSchemas.INTERNAL_SERVER_ERROR, "failed to check api key", nil))
+				}
+				return authorize(c, next, principal, required)
+			}
+
 			// Get header
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
@@ -34,122 +47,56 @@ func JWTMiddleware(h *handlers.Handler, payment_create_required bool) echo.Middl
 			}
 			tokenString := authHeader[7:]
 
-			traceID := ""
-			if v := c.Get("traceId"); v != nil {
-				if s, ok := v.(string); ok {
-					traceID = s
+			// Verify signature, issuer, audience, algorithm and time claims
+			principal, err := h.Verifier.Verify(c.Request().Context(), tokenString)
+			if err != nil {
+				h.Logger.LogRequest(c, gologger.LevelError, logging.TypeAuth, "Rejected token", err)
+				reason := auth.ReasonInvalidClaims
+				var tokenErr *auth.TokenError
+				if errors.As(err, &tokenErr) {
+					reason = tokenErr.Reason
 				}
+				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "invalid token", map[string]interface{}{"reason": reason}))
 			}
 
-			// Jwt parse with keyfunc (resolve key from JWKS cache)
-			keyFunc := func(token *jwt.Token) (interface{}, error) {
-				kid, ok := token.Header["kid"].(string)
-				if !ok || kid == "" {
-					return nil, fmt.Errorf("missing kid header")
-				}
-
-				set, err := h.Jwks.Lookup(context.Background(), h.Config.KeyCloakConfig.URL)
-				if err != nil {
-					return nil, err
-				}
-
-				key, ok := set.LookupKeyID(kid)
-				if !ok {
-					return nil, fmt.Errorf("unable to find JWK for kid %s", kid)
+			// Keep the local profile cache fresh
+			if principal.Username != "" || principal.Name != "" || principal.Email != "" {
+				if err := postgres.UpsertUserProfile(h.DB, c.Request().Context(), principal.UserID, principal.Username, principal.Name, principal.Email); err != nil {
+					h.Logger.LogRequest(c, gologger.LevelWarn, logging.TypeDB, "Failed to update user profile", err)
 				}
-
-				pub, err := key.PublicKey()
-				if err != nil {
-					return nil, err
-				}
-
-				raw, err := jwk.PublicRawKeyOf(pub)
-				if err != nil {
-					return nil, err
-				}
-
-				switch k := raw.(type) {
-				case *rsa.PublicKey:
-					return k, nil
-				case rsa.PublicKey:
-					return &k, nil
-				case *rsa.PrivateKey:
-					return &k.PublicKey, nil
-				case rsa.PrivateKey:
-					return &k.PublicKey, nil
-				case *ecdsa.PublicKey:
-					return k, nil
-				case ecdsa.PublicKey:
-					return &k, nil
-				case *ecdsa.PrivateKey:
-					return &k.PublicKey, nil
-				case ecdsa.PrivateKey:
-					return &k.PublicKey, nil
-				default:
-					return nil, fmt.Errorf("unsupported public key type: %T", raw)
-				}
-			}
-
-			token, err := jwt.Parse(tokenString, keyFunc)
-			if err != nil {
-				h.Logger.Log(gologger.LevelError, gologger.LogType("AUTH"), fmt.Sprintf("Failed to parse token: %v", err), traceID)
-				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "invalid token", nil))
 			}
 
-			// Check keys
-			if !token.Valid {
-				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "SUS token", nil))
-			}
-
-			// Load claims
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
-				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "invalid token claims", nil))
-			}
-
-			// Извлекаем user_id
-			userID, ok := claims["sub"].(string)
-			userUUID, err := uuid.Parse(userID)
-			if err != nil {
-				h.Logger.Log(gologger.LevelError, gologger.LogType("AUTH"), fmt.Sprintf("Invalid user ID in claims: %v", err), traceID)
-				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "invalid user ID in claims", nil))
-			}
-			if !ok {
-				return c.JSON(http.StatusUnauthorized, echokitSchemas.GenError(c, echokitSchemas.UNAUTHORIZED, "wrong claims", nil))
-			}
-
-			// Load scopes
-			if payment_create_required {
-				scopesInterface, ok := claims["scope"]
-				if !ok {
-					return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "missing scopes in token", nil))
-				}
-
-				var scopes []string
-				switch v := scopesInterface.(type) {
-				case string:
-					scopes = append(scopes, strings.Split(v, " ")...)
-				case []interface{}:
-					for _, s := range v {
-						if str, ok := s.(string); ok {
-							scopes = append(scopes, str)
-						}
-					}
-				default:
-					return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "invalid scopes format in token", nil))
-				}
+			return authorize(c, next, principal, required)
+		}
+	}
+}
 
-				hasAdminScope := slices.Contains(scopes, "payment_create")
+func authorize(c echo.Context, next echo.HandlerFunc, principal *auth.Principal, required auth.Requirement) error {
+	// Check route requirements
+	if !required.Allows(principal) {
+		return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "insufficient permissions", map[string]interface{}{"required": required.String()}))
+	}
 
-				if !hasAdminScope {
-					return c.JSON(http.StatusForbidden, echokitSchemas.GenError(c, echokitSchemas.FORBIDDEN, "admin scope required", nil))
-				}
-			}
+	// Передаем principal в контекст
+	auth.SetPrincipal(c, principal)
 
-			// Передаем user_id в контекст
-			c.Set("userID", userUUID)
+	return next(c)
+}
 
-			return next(c)
-		}
+func authenticateApiKey(h *handlers.Handler, c echo.Context, apiKey string) (*auth.Principal, error) {
+	key, err := postgres.GetActiveApiKeyByHash(h.DB, c.Request().Context(), auth.HashApiKey(apiKey))
+	if err != nil {
+		return nil, err
 	}
+	if err := postgres.TouchApiKey(h.DB, c.Request().Context(), key.ID); err != nil {
+		h.Logger.LogRequest(c, gologger.LevelWarn, logging.TypeDB, "Failed to update api key usage", err)
+	}
+
+	return &auth.Principal{
+		UserID:      key.Owner,
+		Issuer:      auth.ApiKeyIssuer,
+		ClientID:    "api_key:" + key.ID.String(),
+		Scopes:      key.Scopes,
+		ClientRoles: map[string][]string{},
+	}, nil
 }
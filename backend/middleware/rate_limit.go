@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	gologger "github.com/nrf24l01/go-logger"
+	echokitSchemas "github.com/nrf24l01/go-web-utils/echokit/schemas"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/logging"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+)
+
+// RateLimitMiddleware must be registered after JWTMiddleware, it keys buckets by userID and clientID.
+func RateLimitMiddleware(h *handlers.Handler, class ratelimit.Class) echo.MiddlewareFunc {
+	return rateLimit(h, func(c echo.Context) (ratelimit.Result, error) {
+		userID := fmt.Sprint(c.Get("userID"))
+		clientID, _ := c.Get("clientID").(string)
+		return h.RateLimiter.Allow(c.Request().Context(), class, userID, clientID)
+	})
+}
+
+// IPRateLimitMiddleware keys buckets by the client address, for public routes without a JWT.
+func IPRateLimitMiddleware(h *handlers.Handler, class ratelimit.Class) echo.MiddlewareFunc {
+	return rateLimit(h, func(c echo.Context) (ratelimit.Result, error) {
+		return h.RateLimiter.AllowIP(c.Request().Context(), class, c.RealIP())
+	})
+}
+
+func rateLimit(h *handlers.Handler, allow func(c echo.Context) (ratelimit.Result, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if h.RateLimiter == nil {
+				return next(c)
+			}
+
+			res, err := allow(c)
+			if err != nil {
+				// Fail open, an unavailable limiter must not take the bank down
+				h.Logger.LogRequest(c, gologger.LevelError, logging.TypeDB, "Failed to check rate limit", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+			header.Set("RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+			header.Set("RateLimit-Reset", strconv.FormatInt(int64(res.Reset.Seconds()), 10))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit, int64(res.Window.Seconds())))
+
+			if !res.Allowed {
+				header.Set("Retry-After", strconv.FormatInt(int64(res.RetryAfter.Seconds()), 10))
+				return c.JSON(http.StatusTooManyRequests, echokitSchemas.GenError(c, echokitSchemas.CustomErrorCode("TOO_MANY_REQUESTS"), "rate limit exceeded", nil))
+			}
+
+			return next(c)
+		}
+	}
+}
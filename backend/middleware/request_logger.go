@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	echoMw "github.com/labstack/echo/v4/middleware"
+	gologger "github.com/nrf24l01/go-logger"
+	"github.com/silaeder-labs/bank/backend/logging"
+)
+
+func RequestLogger(l *logging.Logger) echo.MiddlewareFunc {
+	return echoMw.RequestLoggerWithConfig(echoMw.RequestLoggerConfig{
+		LogLatency:   true,
+		LogRemoteIP:  true,
+		LogMethod:    true,
+		LogURI:       true,
+		LogUserAgent: true,
+		LogStatus:    true,
+		LogError:     true,
+		HandleError:  true,
+		LogValuesFunc: func(c echo.Context, v echoMw.RequestLoggerValues) error {
+			f := logging.FieldsFromContext(c)
+			f.Error = v.Error
+			f.Extra = map[string]any{
+				"method":     v.Method,
+				"uri":        v.URI,
+				"status":     v.Status,
+				"latency_ms": v.Latency.Milliseconds(),
+				"remote_ip":  v.RemoteIP,
+				"user_agent": v.UserAgent,
+			}
+
+			level := gologger.LevelInfo
+			if v.Error != nil || v.Status >= 500 {
+				level = gologger.LevelError
+			} else if v.Status >= 400 {
+				level = gologger.LevelWarn
+			}
+			l.LogFields(level, logging.TypeHTTP, v.Method+" "+v.URI, f)
+			return nil
+		},
+	})
+}
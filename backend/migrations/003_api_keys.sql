@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    owner_id UUID NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    rotated_from UUID REFERENCES api_keys (id),
+    creator_id UUID NOT NULL
+);
+
+CREATE INDEX api_keys_owner_id_idx ON api_keys (owner_id);
+
+CREATE TRIGGER set_updated_at_api_keys
+BEFORE UPDATE ON api_keys
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_api_keys ON api_keys;
+DROP TABLE IF EXISTS api_keys;
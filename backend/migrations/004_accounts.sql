@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Personal accounts reuse the owner's user UUID, so balances.user_id and
+-- transactions.*_user_id keep working as account IDs.
+CREATE TABLE accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('personal', 'organization', 'system')),
+    name VARCHAR(100)
+);
+
+CREATE TABLE account_members (
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    user_id UUID NOT NULL,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'spender', 'viewer')),
+    PRIMARY KEY (account_id, user_id)
+);
+
+CREATE INDEX account_members_user_id_idx ON account_members (user_id);
+
+INSERT INTO accounts (id, kind)
+SELECT user_id, 'personal' FROM balances
+UNION SELECT from_user_id, 'personal' FROM transactions
+UNION SELECT to_user_id, 'personal' FROM transactions
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE transactions ADD COLUMN initiator_id UUID;
+UPDATE transactions SET initiator_id = from_user_id;
+ALTER TABLE transactions ALTER COLUMN initiator_id SET NOT NULL;
+
+CREATE TRIGGER set_updated_at_accounts
+BEFORE UPDATE ON accounts
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER set_updated_at_account_members
+BEFORE UPDATE ON account_members
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_account_members ON account_members;
+DROP TRIGGER IF EXISTS set_updated_at_accounts ON accounts;
+ALTER TABLE transactions DROP COLUMN IF EXISTS initiator_id;
+DROP TABLE IF EXISTS account_members;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE approval_policies (
+    account_id UUID PRIMARY KEY REFERENCES accounts (id),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    threshold_cents BIGINT NOT NULL CHECK (threshold_cents >= 0),
+    required_approvals INT NOT NULL CHECK (required_approvals >= 1),
+    ttl_seconds INT NOT NULL CHECK (ttl_seconds > 0)
+);
+
+CREATE TABLE pending_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    from_account_id UUID NOT NULL REFERENCES accounts (id),
+    to_user_id UUID NOT NULL,
+    initiator_id UUID NOT NULL,
+    amount_cents BIGINT NOT NULL,
+    description VARCHAR(100),
+    required_approvals INT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('PENDING', 'EXECUTED', 'REJECTED', 'EXPIRED')),
+    transaction_id UUID REFERENCES transactions (line_id)
+);
+
+CREATE INDEX pending_transfers_account_status_idx ON pending_transfers (from_account_id, status);
+CREATE INDEX pending_transfers_expires_at_idx ON pending_transfers (expires_at) WHERE status = 'PENDING';
+
+CREATE TABLE transfer_approvals (
+    pending_transfer_id UUID NOT NULL REFERENCES pending_transfers (id),
+    user_id UUID NOT NULL,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decision VARCHAR(16) NOT NULL CHECK (decision IN ('approve', 'reject')),
+    comment VARCHAR(200),
+    PRIMARY KEY (pending_transfer_id, user_id)
+);
+
+CREATE TRIGGER set_updated_at_approval_policies
+BEFORE UPDATE ON approval_policies
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER set_updated_at_pending_transfers
+BEFORE UPDATE ON pending_transfers
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_pending_transfers ON pending_transfers;
+DROP TRIGGER IF EXISTS set_updated_at_approval_policies ON approval_policies;
+DROP TABLE IF EXISTS transfer_approvals;
+DROP TABLE IF EXISTS pending_transfers;
+DROP TABLE IF EXISTS approval_policies;
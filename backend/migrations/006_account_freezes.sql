@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleted_at marks a lifted freeze, expired freezes simply stop matching.
+CREATE TABLE account_freezes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    account_id UUID NOT NULL,
+    direction VARCHAR(16) NOT NULL CHECK (direction IN ('outgoing', 'incoming', 'both')),
+    reason VARCHAR(200) NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_by UUID NOT NULL,
+    lifted_by UUID
+);
+
+CREATE UNIQUE INDEX account_freezes_active_idx ON account_freezes (account_id) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER set_updated_at_account_freezes
+BEFORE UPDATE ON account_freezes
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_account_freezes ON account_freezes;
+DROP TABLE IF EXISTS account_freezes;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Profile cache filled from token claims, Keycloak stays the source of truth.
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    username VARCHAR(255),
+    name VARCHAR(255),
+    email VARCHAR(255),
+    discoverable BOOLEAN NOT NULL DEFAULT false,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX users_username_idx ON users (lower(username) varchar_pattern_ops);
+CREATE INDEX users_email_idx ON users (lower(email));
+
+CREATE TRIGGER set_updated_at_users
+BEFORE UPDATE ON users
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_users ON users;
+DROP TABLE IF EXISTS users;
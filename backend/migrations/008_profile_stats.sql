@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX transactions_from_user_inserted_idx ON transactions (from_user_id, inserted_at) WHERE deleted_at IS NULL;
+CREATE INDEX transactions_to_user_inserted_idx ON transactions (to_user_id, inserted_at) WHERE deleted_at IS NULL;
+CREATE INDEX payments_from_status_idx ON payments (from_id, status) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS payments_from_status_idx;
+DROP INDEX IF EXISTS transactions_to_user_inserted_idx;
+DROP INDEX IF EXISTS transactions_from_user_inserted_idx;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Balance of a user before taken_at, historical balances start from the latest snapshot instead of the first transaction.
+CREATE TABLE balance_snapshots (
+    user_id UUID NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    amount_cents BIGINT NOT NULL,
+    PRIMARY KEY (user_id, taken_at)
+);
+
+CREATE INDEX balance_snapshots_taken_at_idx ON balance_snapshots (taken_at);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS balance_snapshots;
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Label set by the payment creator, visible to both sides.
+ALTER TABLE transactions ADD COLUMN category VARCHAR(64);
+ALTER TABLE payments ADD COLUMN category VARCHAR(64);
+
+-- Category a side assigned to a transaction, overrides the label for that side only.
+CREATE TABLE transaction_categories (
+    transaction_id UUID NOT NULL REFERENCES transactions (line_id),
+    user_id UUID NOT NULL,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    category VARCHAR(64) NOT NULL,
+    PRIMARY KEY (transaction_id, user_id)
+);
+
+-- Daily totals per side, category and counterparty, an empty category means uncategorized.
+CREATE TABLE transaction_rollups (
+    user_id UUID NOT NULL,
+    day DATE NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    counterparty_id UUID NOT NULL,
+    incoming_cents BIGINT NOT NULL,
+    outgoing_cents BIGINT NOT NULL,
+    incoming_count BIGINT NOT NULL,
+    outgoing_count BIGINT NOT NULL,
+    PRIMARY KEY (user_id, day, category, counterparty_id)
+);
+
+-- Days to recompute because a category changed after they were rolled up.
+CREATE TABLE rollup_dirty (
+    user_id UUID NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
+
+-- Transactions inserted before the watermark are already rolled up.
+CREATE TABLE rollup_watermarks (
+    name VARCHAR(64) PRIMARY KEY,
+    watermark TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO rollup_watermarks (name, watermark) VALUES ('transaction_rollups', '-infinity');
+
+CREATE TRIGGER set_updated_at_transaction_categories
+BEFORE UPDATE ON transaction_categories
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_transaction_categories ON transaction_categories;
+DROP TABLE IF EXISTS rollup_watermarks;
+DROP TABLE IF EXISTS rollup_dirty;
+DROP TABLE IF EXISTS transaction_rollups;
+DROP TABLE IF EXISTS transaction_categories;
+ALTER TABLE payments DROP COLUMN IF EXISTS category;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX transactions_inserted_at_idx ON transactions (inserted_at) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS transactions_inserted_at_idx;
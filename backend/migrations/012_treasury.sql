@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'transfer';
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('transfer', 'mint', 'burn'));
+
+INSERT INTO accounts (id, kind, name) VALUES ('00000000-0000-0000-0000-000000000001', 'system', 'Treasury');
+
+-- Money issued so far by unlimited accounts going negative becomes an explicit mint from the treasury
+INSERT INTO transactions (from_user_id, to_user_id, initiator_id, amount_cents, description, type)
+SELECT '00000000-0000-0000-0000-000000000001', user_id, '00000000-0000-0000-0000-000000000001', -amount_cents,
+    'Issued by unlimited account before the treasury', 'mint'
+FROM balances
+WHERE amount_cents < 0 AND deleted_at IS NULL;
+
+INSERT INTO balances (user_id, amount_cents)
+SELECT '00000000-0000-0000-0000-000000000001', COALESCE(sum(amount_cents), 0)
+FROM balances
+WHERE amount_cents < 0 AND deleted_at IS NULL;
+
+UPDATE balances SET amount_cents = 0
+WHERE amount_cents < 0 AND deleted_at IS NULL AND user_id <> '00000000-0000-0000-0000-000000000001';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Irreversible: the backfilled mints moved negative balances to the treasury, and later mints, burns,
+-- snapshots and rollups build on them. Dropping the treasury would leave a ledger that doesn't sum to zero.
+DO $$
+BEGIN
+    RAISE EXCEPTION 'migration 012_treasury can not be rolled back, restore from a backup instead';
+END
+$$;
+-- +goose StatementEnd
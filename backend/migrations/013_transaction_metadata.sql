@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions DROP CONSTRAINT transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('transfer', 'payment', 'refund', 'mint', 'burn', 'fee', 'adjustment'));
+
+-- Client data, filtered with @> so the GIN index applies
+ALTER TABLE transactions ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN external_reference VARCHAR(128);
+
+CREATE INDEX transactions_metadata_idx ON transactions USING GIN (metadata jsonb_path_ops);
+CREATE UNIQUE INDEX transactions_external_reference_idx ON transactions (initiator_id, external_reference)
+    WHERE external_reference IS NOT NULL;
+
+ALTER TABLE payments ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE pending_transfers ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE pending_transfers ADD COLUMN external_reference VARCHAR(128);
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE pending_transfers DROP COLUMN IF EXISTS external_reference;
+ALTER TABLE pending_transfers DROP COLUMN IF EXISTS metadata;
+ALTER TABLE payments DROP COLUMN IF EXISTS metadata;
+DROP INDEX IF EXISTS transactions_external_reference_idx;
+DROP INDEX IF EXISTS transactions_metadata_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_reference;
+ALTER TABLE transactions DROP COLUMN IF EXISTS metadata;
+UPDATE transactions SET type = 'transfer' WHERE type NOT IN ('transfer', 'mint', 'burn');
+ALTER TABLE transactions DROP CONSTRAINT transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('transfer', 'mint', 'burn'));
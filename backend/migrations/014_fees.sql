@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The highest priority matching rule wins, account_kind is the kind of the receiving account (NULL matches any).
+CREATE TABLE fee_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    name VARCHAR(100) NOT NULL,
+    transaction_type VARCHAR(16) NOT NULL,
+    account_kind VARCHAR(16),
+    min_amount BIGINT NOT NULL DEFAULT 0,
+    max_amount BIGINT,
+    model VARCHAR(16) NOT NULL CHECK (model IN ('flat', 'percentage', 'tiered')),
+    flat_cents BIGINT NOT NULL DEFAULT 0,
+    percent_bps INT NOT NULL DEFAULT 0,
+    tiers JSONB NOT NULL DEFAULT '[]',
+    min_fee BIGINT NOT NULL DEFAULT 0,
+    max_fee BIGINT,
+    payer VARCHAR(16) NOT NULL DEFAULT 'source' CHECK (payer IN ('source', 'target')),
+    priority INT NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL
+);
+
+CREATE INDEX fee_rules_type_idx ON fee_rules (transaction_type) WHERE deleted_at IS NULL;
+
+-- Fee transactions point at the transfer they were charged for
+ALTER TABLE transactions ADD COLUMN parent_id UUID REFERENCES transactions (line_id);
+CREATE INDEX transactions_parent_id_idx ON transactions (parent_id) WHERE parent_id IS NOT NULL;
+
+INSERT INTO accounts (id, kind, name) VALUES ('00000000-0000-0000-0000-000000000002', 'system', 'Fees');
+
+CREATE TRIGGER set_updated_at_fee_rules
+BEFORE UPDATE ON fee_rules
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_fee_rules ON fee_rules;
+DELETE FROM accounts WHERE id = '00000000-0000-0000-0000-000000000002';
+DROP INDEX IF EXISTS transactions_parent_id_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS parent_id;
+DROP TABLE IF EXISTS fee_rules;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE payments ADD COLUMN amount_paid BIGINT NOT NULL DEFAULT 0;
+UPDATE payments SET amount_paid = amount WHERE status = 'COMPLETED';
+
+CREATE TABLE payment_installments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    payment_id UUID NOT NULL REFERENCES payments (id),
+    transaction_id UUID NOT NULL REFERENCES transactions (line_id),
+    payer_id UUID NOT NULL,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0)
+);
+
+CREATE INDEX payment_installments_payment_id_idx ON payment_installments (payment_id, inserted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS payment_installments;
+ALTER TABLE payments DROP COLUMN IF EXISTS amount_paid;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Payments without a payer are open requests anyone can pay, amount 0 lets the payer choose
+-- within min_amount/max_amount. Every payment counts as a use.
+ALTER TABLE payments ALTER COLUMN from_id DROP NOT NULL;
+ALTER TABLE payments ADD COLUMN max_uses INT CHECK (max_uses > 0);
+ALTER TABLE payments ADD COLUMN uses INT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN min_amount BIGINT;
+ALTER TABLE payments ADD COLUMN max_amount BIGINT;
+
+UPDATE payments SET uses = (SELECT count(*) FROM payment_installments i WHERE i.payment_id = payments.id);
+-- +goose StatementEnd
+
+-- +goose Down
+DELETE FROM payment_installments WHERE payment_id IN (SELECT id FROM payments WHERE from_id IS NULL);
+DELETE FROM payments WHERE from_id IS NULL;
+ALTER TABLE payments DROP COLUMN IF EXISTS max_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS min_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS uses;
+ALTER TABLE payments DROP COLUMN IF EXISTS max_uses;
+ALTER TABLE payments ALTER COLUMN from_id SET NOT NULL;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE payments ADD COLUMN status_reason VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+UPDATE payments SET status = 'CANCELLED' WHERE status = 'DECLINED';
+ALTER TABLE payments DROP COLUMN IF EXISTS status_reason;
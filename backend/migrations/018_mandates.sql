@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A payer lets a payee collect up to limit_cents per calendar period without confirming every payment
+CREATE TABLE mandates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ,
+    payer_id UUID NOT NULL,
+    payee_id UUID NOT NULL,
+    limit_cents BIGINT NOT NULL CHECK (limit_cents > 0),
+    period VARCHAR(16) NOT NULL CHECK (period IN ('day', 'week', 'month')),
+    description VARCHAR(120),
+    expires_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX mandates_active_idx ON mandates (payer_id, payee_id) WHERE revoked_at IS NULL;
+CREATE INDEX mandates_payee_id_idx ON mandates (payee_id);
+
+CREATE TABLE mandate_debits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    mandate_id UUID NOT NULL REFERENCES mandates (id),
+    payment_id UUID NOT NULL REFERENCES payments (id),
+    transaction_id UUID NOT NULL REFERENCES transactions (line_id),
+    amount_cents BIGINT NOT NULL
+);
+
+CREATE INDEX mandate_debits_mandate_id_idx ON mandate_debits (mandate_id, inserted_at);
+
+ALTER TABLE payments ADD COLUMN mandate_id UUID REFERENCES mandates (id);
+
+CREATE TRIGGER set_updated_at_mandates
+BEFORE UPDATE ON mandates
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_mandates ON mandates;
+ALTER TABLE payments DROP COLUMN IF EXISTS mandate_id;
+DROP TABLE IF EXISTS mandate_debits;
+DROP TABLE IF EXISTS mandates;
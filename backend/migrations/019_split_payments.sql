@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A split shares one bill between several payers, each of them gets an own child payment
+CREATE TABLE payment_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    creator_id UUID NOT NULL,
+    to_id UUID NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    mode VARCHAR(16) NOT NULL CHECK (mode IN ('equal', 'percentage', 'fixed')),
+    status VARCHAR(32) NOT NULL,
+    description VARCHAR(100),
+    category VARCHAR(64)
+);
+
+ALTER TABLE payments ADD COLUMN split_id UUID REFERENCES payment_splits (id);
+
+CREATE INDEX payments_split_id_idx ON payments (split_id) WHERE split_id IS NOT NULL;
+
+CREATE TRIGGER set_updated_at_payment_splits
+BEFORE UPDATE ON payment_splits
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS payments_split_id_idx;
+ALTER TABLE payments DROP COLUMN IF EXISTS split_id;
+DROP TRIGGER IF EXISTS set_updated_at_payment_splits ON payment_splits;
+DROP TABLE IF EXISTS payment_splits;
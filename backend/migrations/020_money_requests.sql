@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Money requests users send to each other, reminded until paid or reminders run out
+ALTER TABLE payments ADD COLUMN peer BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE payments ADD COLUMN reminders_sent INT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN reminded_at TIMESTAMPTZ;
+
+CREATE INDEX payments_peer_open_idx ON payments (creator_id) WHERE peer AND status IN ('UNPAID', 'PARTIALLY_PAID');
+
+-- A muted requester can still ask for money but sends no notifications, a blocked one can't ask at all
+CREATE TABLE money_request_blocks (
+    user_id UUID NOT NULL,
+    blocked_id UUID NOT NULL,
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    mode VARCHAR(16) NOT NULL CHECK (mode IN ('mute', 'block')),
+    PRIMARY KEY (user_id, blocked_id)
+);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    inserted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    read_at TIMESTAMPTZ,
+    user_id UUID NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    payment_id UUID REFERENCES payments (id)
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, inserted_at);
+
+CREATE TRIGGER set_updated_at_money_request_blocks
+BEFORE UPDATE ON money_request_blocks
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS set_updated_at_money_request_blocks ON money_request_blocks;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS money_request_blocks;
+DROP INDEX IF EXISTS payments_peer_open_idx;
+ALTER TABLE payments DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS reminders_sent;
+ALTER TABLE payments DROP COLUMN IF EXISTS peer;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A pending transfer reserves its external reference until it is executed, rejected or expired
+CREATE UNIQUE INDEX pending_transfers_external_reference_idx ON pending_transfers (initiator_id, external_reference)
+    WHERE external_reference IS NOT NULL AND status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS pending_transfers_external_reference_idx;
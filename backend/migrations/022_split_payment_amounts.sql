@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every share of a split is a positive amount, a zero or negative child would never complete
+ALTER TABLE payments ADD CONSTRAINT payments_split_amount_check CHECK (split_id IS NULL OR amount > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_split_amount_check;
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type Account struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+
+	Kind        schemas.AccountKind
+	Name        string
+	Role        schemas.AccountRole
+	AmountCents int64
+}
+
+type AccountMember struct {
+	AccountID  uuid.UUID
+	UserID     uuid.UUID
+	InsertedAt time.Time
+	Role       schemas.AccountRole
+}
+
+func (a *Account) ToAccountFull() schemas.AccountFull {
+	return schemas.AccountFull{
+		ID:        a.ID.String(),
+		CreatedAt: a.InsertedAt.Format(time.RFC3339),
+		Kind:      a.Kind,
+		Name:      a.Name,
+		Role:      a.Role,
+		Balance:   a.AmountCents,
+	}
+}
+
+func (m *AccountMember) ToAccountMemberFull() schemas.AccountMemberFull {
+	return schemas.AccountMemberFull{
+		UserID:  m.UserID.String(),
+		Role:    m.Role,
+		AddedAt: m.InsertedAt.Format(time.RFC3339),
+	}
+}
+
+func CreateOrganizationAccount(db *pgkit.DB, ctx context.Context, name string, owner uuid.UUID) (*Account, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	a := Account{Kind: schemas.AccountOrganization, Name: name, Role: schemas.RoleOwner}
+	if err := tx.QueryRow(ctx, "INSERT INTO accounts (kind, name) VALUES ($1, $2) RETURNING id, inserted_at, updated_at", a.Kind, a.Name).
+		Scan(&a.ID, &a.InsertedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO account_members (account_id, user_id, role) VALUES ($1, $2, $3)", a.ID, owner, schemas.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+	return &a, nil
+}
+
+// GetAccountForUser returns the account with the user's role in it.
+// The personal account of a user is the one with the same ID, it may not have a row yet.
+func GetAccountForUser(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, userID uuid.UUID) (*Account, error) {
+	var a Account
+	err := db.Pool.QueryRow(ctx, `
+		SELECT a.id, a.inserted_at, a.updated_at, a.kind, COALESCE(a.name, ''),
+			CASE WHEN a.kind = 'personal' AND a.id = $2 THEN 'owner' ELSE m.role END,
+			COALESCE(b.amount_cents, 0)
+		FROM accounts a
+		LEFT JOIN account_members m ON m.account_id = a.id AND m.user_id = $2
+		LEFT JOIN balances b ON b.user_id = a.id AND b.deleted_at IS NULL
+		WHERE a.id = $1 AND a.deleted_at IS NULL AND ((a.kind = 'personal' AND a.id = $2) OR m.role IS NOT NULL)
+	`, accountID, userID).Scan(&a.ID, &a.InsertedAt, &a.UpdatedAt, &a.Kind, &a.Name, &a.Role, &a.AmountCents)
+	if err == pgx.ErrNoRows && accountID == userID {
+		return &Account{ID: userID, InsertedAt: time.Now(), Kind: schemas.AccountPersonal, Role: schemas.RoleOwner}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetAccountRole returns the user's role in the account, pgx.ErrNoRows when the user is not a member.
+func GetAccountRole(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, userID uuid.UUID) (schemas.AccountRole, error) {
+	if accountID == userID {
+		return schemas.RoleOwner, nil
+	}
+	var role schemas.AccountRole
+	err := db.Pool.QueryRow(ctx, `
+		SELECT m.role
+		FROM account_members m
+		JOIN accounts a ON a.id = m.account_id
+		WHERE m.account_id = $1 AND m.user_id = $2 AND a.deleted_at IS NULL
+	`, accountID, userID).Scan(&role)
+	return role, err
+}
+
+func GetAccountKind(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) (schemas.AccountKind, error) {
+	var kind schemas.AccountKind
+	err := db.Pool.QueryRow(ctx, "SELECT kind FROM accounts WHERE id = $1 AND deleted_at IS NULL", accountID).Scan(&kind)
+	if err == pgx.ErrNoRows {
+		return schemas.AccountPersonal, nil
+	}
+	return kind, err
+}
+
+func ListAccountsForUser(db *pgkit.DB, ctx context.Context, userID uuid.UUID) ([]Account, error) {
+	personal, err := GetAccountForUser(db, ctx, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := []Account{*personal}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT a.id, a.inserted_at, a.updated_at, a.kind, COALESCE(a.name, ''), m.role, COALESCE(b.amount_cents, 0)
+		FROM account_members m
+		JOIN accounts a ON a.id = m.account_id
+		LEFT JOIN balances b ON b.user_id = a.id AND b.deleted_at IS NULL
+		WHERE m.user_id = $1 AND a.deleted_at IS NULL AND a.id <> $1
+		ORDER BY a.inserted_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Account
+		if err := rows.Scan(&a.ID, &a.InsertedAt, &a.UpdatedAt, &a.Kind, &a.Name, &a.Role, &a.AmountCents); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func ListAccountMembers(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) ([]AccountMember, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT account_id, user_id, inserted_at, role FROM account_members WHERE account_id = $1 ORDER BY inserted_at", accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []AccountMember
+	for rows.Next() {
+		var m AccountMember
+		if err := rows.Scan(&m.AccountID, &m.UserID, &m.InsertedAt, &m.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetAccountMember adds a member or changes the role, refusing to demote the last owner.
+func SetAccountMember(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, userID uuid.UUID, role schemas.AccountRole) (*AccountMember, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if role != schemas.RoleOwner {
+		if err := ensureAnotherOwnerTx(tx, ctx, accountID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	m := AccountMember{AccountID: accountID, UserID: userID, Role: role}
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO account_members (account_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (account_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING inserted_at
+	`, accountID, userID, role).Scan(&m.InsertedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+	return &m, nil
+}
+
+func RemoveAccountMember(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, userID uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err := ensureAnotherOwnerTx(tx, ctx, accountID, userID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, "DELETE FROM account_members WHERE account_id = $1 AND user_id = $2", accountID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// ensureAnotherOwnerTx locks the owners of the account and fails if userID is the only one.
+func ensureAnotherOwnerTx(tx pgx.Tx, ctx context.Context, accountID uuid.UUID, userID uuid.UUID) error {
+	rows, err := tx.Query(ctx, "SELECT user_id FROM account_members WHERE account_id = $1 AND role = 'owner' FOR UPDATE", accountID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	others := 0
+	for rows.Next() {
+		var owner uuid.UUID
+		if err := rows.Scan(&owner); err != nil {
+			return err
+		}
+		if owner != userID {
+			others++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func ensureAccountsTx(tx pgx.Tx, ctx context.Context, ids ...uuid.UUID) error {
+	_, err := tx.Exec(ctx, "INSERT INTO accounts (id, kind) SELECT unnest($1::uuid[]), 'personal' ON CONFLICT (id) DO NOTHING", ids)
+	return err
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+const transactionRollupsWatermark = "transaction_rollups"
+
+type AnalyticsRow struct {
+	Start            time.Time
+	Category         string
+	CounterpartyID   uuid.UUID
+	CounterpartyName string
+	IncomingCents    int64
+	OutgoingCents    int64
+	IncomingCount    int64
+	OutgoingCount    int64
+}
+
+func (r *AnalyticsRow) ToAnalyticsRowFull() schemas.AnalyticsRowFull {
+	full := schemas.AnalyticsRowFull{
+		Start:            r.Start.Format("2006-01-02"),
+		Category:         r.Category,
+		CounterpartyName: r.CounterpartyName,
+		Incoming:         r.IncomingCents,
+		Outgoing:         r.OutgoingCents,
+		IncomingCount:    r.IncomingCount,
+		OutgoingCount:    r.OutgoingCount,
+	}
+	if r.CounterpartyID != uuid.Nil {
+		full.CounterpartyID = r.CounterpartyID.String()
+	}
+	return full
+}
+
+// SetTransactionCategory sets the category userID sees for a transaction it is a side of, an empty category resets it.
+// The day of the transaction is marked for the next rollup refresh.
+func SetTransactionCategory(db *pgkit.DB, ctx context.Context, transactionID, userID uuid.UUID, category string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var insertedAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT inserted_at FROM transactions
+		WHERE line_id = $1 AND deleted_at IS NULL AND (from_user_id = $2 OR to_user_id = $2)
+	`, transactionID, userID).Scan(&insertedAt)
+	if err != nil {
+		return err
+	}
+
+	if category == "" {
+		_, err = tx.Exec(ctx, "DELETE FROM transaction_categories WHERE transaction_id = $1 AND user_id = $2", transactionID, userID)
+	} else {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO transaction_categories (transaction_id, user_id, category)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (transaction_id, user_id) DO UPDATE SET category = EXCLUDED.category
+		`, transactionID, userID, category)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO rollup_dirty (user_id, day) VALUES ($1, ($2::timestamptz AT TIME ZONE 'UTC')::date)
+		ON CONFLICT DO NOTHING
+	`, userID, insertedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// RefreshTransactionRollups recomputes the days touched by transactions inserted since the watermark
+// and the days marked dirty by category changes, then moves the watermark to cutoff.
+func RefreshTransactionRollups(db *pgkit.DB, ctx context.Context, cutoff time.Time) (int64, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var watermark time.Time
+	err = tx.QueryRow(ctx, "SELECT watermark FROM rollup_watermarks WHERE name = $1 FOR UPDATE", transactionRollupsWatermark).Scan(&watermark)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE TEMP TABLE rollup_batch (user_id UUID, day DATE, PRIMARY KEY (user_id, day)) ON COMMIT DROP"); err != nil {
+		return 0, err
+	}
+	// Only the dirty rows deleted here are processed, rows marked meanwhile wait for the next run
+	if _, err := tx.Exec(ctx, `
+		WITH taken AS (DELETE FROM rollup_dirty RETURNING user_id, day)
+		INSERT INTO rollup_batch SELECT user_id, day FROM taken
+	`); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO rollup_batch
+		SELECT from_user_id, (inserted_at AT TIME ZONE 'UTC')::date FROM transactions
+		WHERE inserted_at >= $1 AND inserted_at < $2
+		UNION
+		SELECT to_user_id, (inserted_at AT TIME ZONE 'UTC')::date FROM transactions
+		WHERE inserted_at >= $1 AND inserted_at < $2
+		ON CONFLICT DO NOTHING
+	`, watermark, cutoff); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM transaction_rollups r USING rollup_batch b WHERE r.user_id = b.user_id AND r.day = b.day"); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO transaction_rollups (user_id, day, category, counterparty_id, incoming_cents, outgoing_cents, incoming_count, outgoing_count)
+		SELECT l.user_id, l.day, l.category, l.counterparty_id,
+			sum(l.incoming)::bigint, sum(l.outgoing)::bigint,
+			count(*) FILTER (WHERE l.incoming > 0), count(*) FILTER (WHERE l.outgoing > 0)
+		FROM (
+			SELECT b.user_id, b.day, COALESCE(tc.category, t.category, '') AS category,
+				t.from_user_id AS counterparty_id, t.amount_cents AS incoming, 0::bigint AS outgoing
+			FROM rollup_batch b
+			JOIN transactions t ON t.to_user_id = b.user_id AND t.deleted_at IS NULL
+				AND t.inserted_at >= b.day::timestamp AT TIME ZONE 'UTC'
+				AND t.inserted_at < (b.day + 1)::timestamp AT TIME ZONE 'UTC'
+			LEFT JOIN transaction_categories tc ON tc.transaction_id = t.line_id AND tc.user_id = b.user_id
+			UNION ALL
+			SELECT b.user_id, b.day, COALESCE(tc.category, t.category, ''),
+				t.to_user_id, 0::bigint, t.amount_cents
+			FROM rollup_batch b
+			JOIN transactions t ON t.from_user_id = b.user_id AND t.deleted_at IS NULL
+				AND t.inserted_at >= b.day::timestamp AT TIME ZONE 'UTC'
+				AND t.inserted_at < (b.day + 1)::timestamp AT TIME ZONE 'UTC'
+			LEFT JOIN transaction_categories tc ON tc.transaction_id = t.line_id AND tc.user_id = b.user_id
+		) l
+		GROUP BY l.user_id, l.day, l.category, l.counterparty_id
+	`); err != nil {
+		return 0, err
+	}
+
+	var days int64
+	if err := tx.QueryRow(ctx, "SELECT count(*) FROM rollup_batch").Scan(&days); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE rollup_watermarks SET watermark = $2 WHERE name = $1", transactionRollupsWatermark, cutoff); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	committed = true
+	return days, nil
+}
+
+// GetAnalytics sums rolled up days between from and to (inclusive dates) per granularity bucket,
+// splitting by category and counterparty only when asked to.
+func GetAnalytics(db *pgkit.DB, ctx context.Context, userID uuid.UUID, from, to time.Time, granularity string, byCategory, byCounterparty bool) ([]AnalyticsRow, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT a.start, a.category, COALESCE(a.counterparty_id, '00000000-0000-0000-0000-000000000000'),
+			COALESCE(NULLIF(u.name, ''), u.username, ac.name, ''),
+			a.incoming, a.outgoing, a.incoming_count, a.outgoing_count
+		FROM (
+			SELECT date_trunc($4, r.day::timestamp)::date AS start,
+				CASE WHEN $5::boolean THEN r.category ELSE '' END AS category,
+				CASE WHEN $6::boolean THEN r.counterparty_id END AS counterparty_id,
+				sum(r.incoming_cents)::bigint AS incoming, sum(r.outgoing_cents)::bigint AS outgoing,
+				sum(r.incoming_count)::bigint AS incoming_count, sum(r.outgoing_count)::bigint AS outgoing_count
+			FROM transaction_rollups r
+			WHERE r.user_id = $1 AND r.day >= $2::date AND r.day <= $3::date
+			GROUP BY 1, 2, 3
+		) a
+		LEFT JOIN users u ON u.id = a.counterparty_id
+		LEFT JOIN accounts ac ON ac.id = a.counterparty_id
+		ORDER BY a.start, a.category, a.counterparty_id
+	`, userID, from, to, granularity, byCategory, byCounterparty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []AnalyticsRow
+	for rows.Next() {
+		var r AnalyticsRow
+		if err := rows.Scan(&r.Start, &r.Category, &r.CounterpartyID, &r.CounterpartyName, &r.IncomingCents, &r.OutgoingCents, &r.IncomingCount, &r.OutgoingCount); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
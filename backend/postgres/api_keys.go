@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type ApiKey struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+
+	Name        string
+	Prefix      string
+	Hash        []byte
+	Owner       uuid.UUID
+	Scopes      []string
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	RotatedFrom *uuid.UUID
+	Creator     uuid.UUID
+}
+
+const apiKeyColumns = "id, inserted_at, updated_at, deleted_at, name, prefix, owner_id, scopes, expires_at, last_used_at, rotated_from, creator_id"
+
+func scanApiKey(row pgx.Row, k *ApiKey) error {
+	return row.Scan(&k.ID, &k.InsertedAt, &k.UpdatedAt, &k.DeletedAt, &k.Name, &k.Prefix, &k.Owner, &k.Scopes, &k.ExpiresAt, &k.LastUsedAt, &k.RotatedFrom, &k.Creator)
+}
+
+func (k *ApiKey) ToApiKeyFull() schemas.ApiKeyFull {
+	full := schemas.ApiKeyFull{
+		ID:        k.ID.String(),
+		CreatedAt: k.InsertedAt.Format(time.RFC3339),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		OwnerID:   k.Owner.String(),
+		Scopes:    k.Scopes,
+		Revoked:   k.DeletedAt != nil,
+	}
+	if k.ExpiresAt != nil {
+		full.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+	if k.LastUsedAt != nil {
+		full.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	if k.RotatedFrom != nil {
+		full.RotatedFrom = k.RotatedFrom.String()
+	}
+	return full
+}
+
+func (k *ApiKey) Insert(db *pgkit.DB, ctx context.Context) error {
+	if k.Scopes == nil {
+		k.Scopes = []string{}
+	}
+	return db.Pool.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, owner_id, scopes, expires_at, rotated_from, creator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, inserted_at, updated_at
+	`, k.Name, k.Prefix, k.Hash, k.Owner, k.Scopes, k.ExpiresAt, k.RotatedFrom, k.Creator).Scan(&k.ID, &k.InsertedAt, &k.UpdatedAt)
+}
+
+func insertApiKeyTx(tx pgx.Tx, ctx context.Context, k *ApiKey) error {
+	if k.Scopes == nil {
+		k.Scopes = []string{}
+	}
+	return tx.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, owner_id, scopes, expires_at, rotated_from, creator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, inserted_at, updated_at
+	`, k.Name, k.Prefix, k.Hash, k.Owner, k.Scopes, k.ExpiresAt, k.RotatedFrom, k.Creator).Scan(&k.ID, &k.InsertedAt, &k.UpdatedAt)
+}
+
+// GetActiveApiKeyByHash returns a key that is neither revoked nor expired.
+func GetActiveApiKeyByHash(db *pgkit.DB, ctx context.Context, hash []byte) (*ApiKey, error) {
+	var k ApiKey
+	err := scanApiKey(db.Pool.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+	`, hash), &k)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// TouchApiKey records usage at most once a minute to avoid a write on every request.
+func TouchApiKey(db *pgkit.DB, ctx context.Context, id uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, id)
+	return err
+}
+
+func GetApiKeyByID(db *pgkit.DB, ctx context.Context, id uuid.UUID) (*ApiKey, error) {
+	var k ApiKey
+	if err := scanApiKey(db.Pool.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id), &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func ListApiKeys(db *pgkit.DB, ctx context.Context, limit, offset int) ([]ApiKey, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY inserted_at DESC LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ApiKey
+	for rows.Next() {
+		var k ApiKey
+		if err := scanApiKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func RevokeApiKey(db *pgkit.DB, ctx context.Context, id uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "UPDATE api_keys SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// RotateApiKey issues next with the old key's owner and scopes, the old key keeps working for grace.
+// next keeps the old expiry unless it has its own, expired keys can't be rotated.
+func RotateApiKey(db *pgkit.DB, ctx context.Context, oldID uuid.UUID, next *ApiKey, grace time.Duration) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var old ApiKey
+	err = scanApiKey(tx.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", oldID), &old)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrApiKeyNotFound
+		}
+		return err
+	}
+
+	if old.ExpiresAt != nil && !old.ExpiresAt.After(time.Now()) {
+		return ErrApiKeyExpired
+	}
+
+	next.Name = old.Name
+	next.Owner = old.Owner
+	next.Scopes = old.Scopes
+	if next.ExpiresAt == nil {
+		next.ExpiresAt = old.ExpiresAt
+	}
+	next.RotatedFrom = &old.ID
+	if err := insertApiKeyTx(tx, ctx, next); err != nil {
+		return err
+	}
+
+	if grace > 0 {
+		_, err = tx.Exec(ctx, "UPDATE api_keys SET expires_at = LEAST(COALESCE(expires_at, 'infinity'), now() + make_interval(secs => $2)) WHERE id = $1", old.ID, grace.Seconds())
+	} else {
+		_, err = tx.Exec(ctx, "UPDATE api_keys SET deleted_at = now() WHERE id = $1", old.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,359 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type ApprovalPolicy struct {
+	AccountID  uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+
+	ThresholdCents    int64
+	RequiredApprovals int
+	TTLSeconds        int
+}
+
+type PendingTransfer struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+
+	From              uuid.UUID
+	To                uuid.UUID
+	Initiator         uuid.UUID
+	AmountCents       int64
+	Description       string
+	RequiredApprovals int
+	ExpiresAt         time.Time
+	Status            schemas.PendingTransferStatus
+	TransactionID     *uuid.UUID
+	Approvals         []TransferApproval
+
+	Metadata          map[string]any
+	ExternalReference string
+}
+
+type TransferApproval struct {
+	PendingTransferID uuid.UUID
+	UserID            uuid.UUID
+	InsertedAt        time.Time
+	Decision          schemas.ApprovalDecision
+	Comment           string
+}
+
+const pendingTransferColumns = "id, inserted_at, updated_at, from_account_id, to_user_id, initiator_id, amount_cents, COALESCE(description, ''), required_approvals, expires_at, status, transaction_id, metadata, COALESCE(external_reference, '')"
+
+func scanPendingTransfer(row pgx.Row, p *PendingTransfer) error {
+	return row.Scan(&p.ID, &p.InsertedAt, &p.UpdatedAt, &p.From, &p.To, &p.Initiator, &p.AmountCents, &p.Description, &p.RequiredApprovals, &p.ExpiresAt, &p.Status, &p.TransactionID, &p.Metadata, &p.ExternalReference)
+}
+
+func (p *ApprovalPolicy) ToApprovalPolicyFull() schemas.ApprovalPolicyFull {
+	return schemas.ApprovalPolicyFull{
+		AccountID:         p.AccountID.String(),
+		UpdatedAt:         p.UpdatedAt.Format(time.RFC3339),
+		Threshold:         p.ThresholdCents,
+		RequiredApprovals: p.RequiredApprovals,
+		TTLSeconds:        p.TTLSeconds,
+	}
+}
+
+// Applies reports whether a transfer of amountCents has to be approved.
+func (p *ApprovalPolicy) Applies(amountCents int64) bool {
+	return amountCents > p.ThresholdCents
+}
+
+func (p *PendingTransfer) ToPendingTransferFull() schemas.PendingTransferFull {
+	full := schemas.PendingTransferFull{
+		ID:                p.ID.String(),
+		CreatedAt:         p.InsertedAt.Format(time.RFC3339),
+		ExpiresAt:         p.ExpiresAt.Format(time.RFC3339),
+		Status:            p.Status,
+		Source:            p.From.String(),
+		Target:            p.To.String(),
+		Initiator:         p.Initiator.String(),
+		Amount:            p.AmountCents,
+		Comment:           p.Description,
+		RequiredApprovals: p.RequiredApprovals,
+		Approvals:         []schemas.TransferApprovalFull{},
+		Metadata:          p.Metadata,
+		ExternalReference: p.ExternalReference,
+	}
+	for _, a := range p.Approvals {
+		full.Approvals = append(full.Approvals, schemas.TransferApprovalFull{
+			UserID:    a.UserID.String(),
+			Decision:  a.Decision,
+			Comment:   a.Comment,
+			CreatedAt: a.InsertedAt.Format(time.RFC3339),
+		})
+	}
+	if p.TransactionID != nil {
+		full.TransactionID = p.TransactionID.String()
+	}
+	return full
+}
+
+func GetApprovalPolicy(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) (*ApprovalPolicy, error) {
+	p := ApprovalPolicy{AccountID: accountID}
+	err := db.Pool.QueryRow(ctx, "SELECT inserted_at, updated_at, threshold_cents, required_approvals, ttl_seconds FROM approval_policies WHERE account_id = $1", accountID).
+		Scan(&p.InsertedAt, &p.UpdatedAt, &p.ThresholdCents, &p.RequiredApprovals, &p.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func SetApprovalPolicy(db *pgkit.DB, ctx context.Context, p *ApprovalPolicy) error {
+	return db.Pool.QueryRow(ctx, `
+		INSERT INTO approval_policies (account_id, threshold_cents, required_approvals, ttl_seconds)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id) DO UPDATE
+		SET threshold_cents = EXCLUDED.threshold_cents, required_approvals = EXCLUDED.required_approvals, ttl_seconds = EXCLUDED.ttl_seconds
+		RETURNING inserted_at, updated_at
+	`, p.AccountID, p.ThresholdCents, p.RequiredApprovals, p.TTLSeconds).Scan(&p.InsertedAt, &p.UpdatedAt)
+}
+
+func DeleteApprovalPolicy(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "DELETE FROM approval_policies WHERE account_id = $1", accountID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// CreatePendingTransfer parks the draft until the policy's quorum approves it. The external reference
+// is checked against executed transfers and reserved among pending ones, so a duplicate fails with
+// ErrDuplicateReference now rather than at the final approval.
+func CreatePendingTransfer(db *pgkit.DB, ctx context.Context, draft Transaction, policy *ApprovalPolicy) (*PendingTransfer, error) {
+	p := PendingTransfer{
+		From:              draft.From,
+		To:                draft.To,
+		Initiator:         draft.Initiator,
+		AmountCents:       draft.AmountCents,
+		Description:       draft.Description,
+		RequiredApprovals: policy.RequiredApprovals,
+		Status:            schemas.TransferPending,
+		Metadata:          draft.Metadata,
+		ExternalReference: draft.ExternalReference,
+	}
+	if p.Metadata == nil {
+		p.Metadata = map[string]any{}
+	}
+	if p.ExternalReference != "" {
+		var taken bool
+		if err := db.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM transactions WHERE initiator_id = $1 AND external_reference = $2)", p.Initiator, p.ExternalReference).Scan(&taken); err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrDuplicateReference
+		}
+	}
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO pending_transfers (from_account_id, to_user_id, initiator_id, amount_cents, description, required_approvals, expires_at, status, metadata, external_reference)
+		VALUES ($1, $2, $3, $4, $5, $6, now() + make_interval(secs => $7), $8, $9, NULLIF($10, ''))
+		RETURNING id, inserted_at, updated_at, expires_at
+	`, p.From, p.To, p.Initiator, p.AmountCents, p.Description, p.RequiredApprovals, policy.TTLSeconds, p.Status, p.Metadata, p.ExternalReference).
+		Scan(&p.ID, &p.InsertedAt, &p.UpdatedAt, &p.ExpiresAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "pending_transfers_external_reference_idx" {
+		return nil, ErrDuplicateReference
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func GetPendingTransfer(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, id uuid.UUID) (*PendingTransfer, error) {
+	var p PendingTransfer
+	if err := scanPendingTransfer(db.Pool.QueryRow(ctx, "SELECT "+pendingTransferColumns+" FROM pending_transfers WHERE id = $1 AND from_account_id = $2", id, accountID), &p); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, "SELECT pending_transfer_id, user_id, inserted_at, decision, COALESCE(comment, '') FROM transfer_approvals WHERE pending_transfer_id = $1 ORDER BY inserted_at", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a TransferApproval
+		if err := rows.Scan(&a.PendingTransferID, &a.UserID, &a.InsertedAt, &a.Decision, &a.Comment); err != nil {
+			return nil, err
+		}
+		p.Approvals = append(p.Approvals, a)
+	}
+	return &p, rows.Err()
+}
+
+// ListPendingTransfers returns transfers of the account without approvals, an empty status matches all.
+func ListPendingTransfers(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, status string, limit, offset int) ([]PendingTransfer, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+pendingTransferColumns+" FROM pending_transfers WHERE from_account_id = $1 AND ($2 = '' OR status = $2) ORDER BY inserted_at DESC LIMIT $3 OFFSET $4",
+		accountID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []PendingTransfer
+	for rows.Next() {
+		var p PendingTransfer
+		if err := scanPendingTransfer(rows, &p); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, p)
+	}
+	return transfers, rows.Err()
+}
+
+// DecideTransfer records an approval or rejection. Once approvals reach the quorum the transfer
+// is executed in the same database transaction. When the source can't pay, an account is frozen or
+// the external reference is taken, the approvals are kept, the transfer stays pending and the error
+// is returned, so any approver can retry later.
+func DecideTransfer(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, id uuid.UUID, userID uuid.UUID, decision schemas.ApprovalDecision, comment string) (*PendingTransfer, error) {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var p PendingTransfer
+	if err := scanPendingTransfer(tx.QueryRow(ctx, "SELECT "+pendingTransferColumns+" FROM pending_transfers WHERE id = $1 AND from_account_id = $2 FOR UPDATE", id, accountID), &p); err != nil {
+		return nil, err
+	}
+
+	var resultErr error
+	switch {
+	case p.Status != schemas.TransferPending:
+		return nil, ErrTransferNotPending
+	case !p.ExpiresAt.After(time.Now()):
+		if _, err := tx.Exec(ctx, "UPDATE pending_transfers SET status = $2 WHERE id = $1", id, schemas.TransferExpired); err != nil {
+			return nil, err
+		}
+		resultErr = ErrTransferNotPending
+	case decision == schemas.DecisionApprove && userID == p.Initiator:
+		return nil, ErrSelfApproval
+	case decision == schemas.DecisionReject:
+		if err := insertTransferApprovalTx(tx, ctx, id, userID, decision, comment); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, "UPDATE pending_transfers SET status = $2 WHERE id = $1", id, schemas.TransferRejected); err != nil {
+			return nil, err
+		}
+	default:
+		if err := insertTransferApprovalTx(tx, ctx, id, userID, decision, comment); err != nil {
+			return nil, err
+		}
+		if err := executeIfApprovedTx(tx, ctx, &p); err == ErrCantPay || err == ErrSourceFrozen || err == ErrTargetFrozen || err == ErrDuplicateReference {
+			resultErr = err
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	if resultErr != nil {
+		return nil, resultErr
+	}
+	return GetPendingTransfer(db, ctx, accountID, id)
+}
+
+// insertTransferApprovalTx records the decision once, repeating the same decision is a retry and
+// keeps the first record, changing it fails with ErrDecisionChanged so the audit trail stays intact.
+func insertTransferApprovalTx(tx pgx.Tx, ctx context.Context, id uuid.UUID, userID uuid.UUID, decision schemas.ApprovalDecision, comment string) error {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO transfer_approvals (pending_transfer_id, user_id, decision, comment)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (pending_transfer_id, user_id) DO NOTHING
+	`, id, userID, decision, comment)
+	if err != nil || tag.RowsAffected() > 0 {
+		return err
+	}
+
+	var previous schemas.ApprovalDecision
+	if err := tx.QueryRow(ctx, "SELECT decision FROM transfer_approvals WHERE pending_transfer_id = $1 AND user_id = $2", id, userID).Scan(&previous); err != nil {
+		return err
+	}
+	if previous != decision {
+		return ErrDecisionChanged
+	}
+	return nil
+}
+
+// countApprovalsTx counts approvals of users who may still spend from the account, an approver
+// removed or demoted to viewer since no longer counts toward the quorum.
+func countApprovalsTx(tx pgx.Tx, ctx context.Context, p *PendingTransfer) (int, error) {
+	var approvals int
+	err := tx.QueryRow(ctx, `
+		SELECT count(*)
+		FROM transfer_approvals a
+		LEFT JOIN account_members m ON m.account_id = $2 AND m.user_id = a.user_id
+		WHERE a.pending_transfer_id = $1 AND a.decision = $3 AND (a.user_id = $2 OR m.role IN ($4, $5))
+	`, p.ID, p.From, schemas.DecisionApprove, schemas.RoleOwner, schemas.RoleSpender).Scan(&approvals)
+	return approvals, err
+}
+
+// executeIfApprovedTx runs the transfer in a savepoint, so tx is still usable when it fails.
+func executeIfApprovedTx(tx pgx.Tx, ctx context.Context, p *PendingTransfer) error {
+	approvals, err := countApprovalsTx(tx, ctx, p)
+	if err != nil {
+		return err
+	}
+	if approvals < p.RequiredApprovals {
+		return nil
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	transaction, err := makeTransactionTx(savepoint, ctx, Transaction{
+		From:        p.From,
+		To:          p.To,
+		Initiator:   p.Initiator,
+		AmountCents: p.AmountCents,
+		Description: p.Description,
+
+		Metadata:          p.Metadata,
+		ExternalReference: p.ExternalReference,
+	})
+	if err != nil {
+		_ = savepoint.Rollback(ctx)
+		return err
+	}
+	if err := savepoint.Commit(ctx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE pending_transfers SET status = $2, transaction_id = $3 WHERE id = $1", p.ID, schemas.TransferExecuted, transaction.LineID)
+	return err
+}
+
+// ExpirePendingTransfers marks overdue pending transfers as expired and returns how many were.
+func ExpirePendingTransfers(db *pgkit.DB, ctx context.Context) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, "UPDATE pending_transfers SET status = $1 WHERE status = $2 AND expires_at <= now()", schemas.TransferExpired, schemas.TransferPending)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
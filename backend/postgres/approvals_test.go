@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// TestCountApprovals needs a migrated database in TEST_DATABASE_URL, everything it writes is rolled back.
+func TestCountApprovals(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	owner, spender, removed, demoted := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	p := PendingTransfer{From: uuid.New(), RequiredApprovals: 2}
+	mustExec(t, tx, "INSERT INTO accounts (id, kind, name) VALUES ($1, 'organization', 'Test')", p.From)
+	for user, role := range map[uuid.UUID]schemas.AccountRole{owner: schemas.RoleOwner, spender: schemas.RoleSpender, removed: schemas.RoleSpender, demoted: schemas.RoleSpender} {
+		mustExec(t, tx, "INSERT INTO account_members (account_id, user_id, role) VALUES ($1, $2, $3)", p.From, user, role)
+	}
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO pending_transfers (from_account_id, to_user_id, initiator_id, amount_cents, required_approvals, expires_at, status)
+		VALUES ($1, $2, $3, 100, $4, $5, 'PENDING')
+		RETURNING id
+	`, p.From, uuid.New(), owner, p.RequiredApprovals, time.Now().Add(time.Hour)).Scan(&p.ID); err != nil {
+		t.Fatalf("insert pending transfer: %v", err)
+	}
+
+	steps := []struct {
+		name  string
+		query string
+		args  []any
+		want  int
+	}{
+		{"removed member approves", "INSERT INTO transfer_approvals (pending_transfer_id, user_id, decision) VALUES ($1, $2, 'approve')", []any{p.ID, removed}, 1},
+		{"demoted member approves", "INSERT INTO transfer_approvals (pending_transfer_id, user_id, decision) VALUES ($1, $2, 'approve')", []any{p.ID, demoted}, 2},
+		{"approver is removed", "DELETE FROM account_members WHERE account_id = $1 AND user_id = $2", []any{p.From, removed}, 1},
+		{"approver is demoted to viewer", "UPDATE account_members SET role = 'viewer' WHERE account_id = $1 AND user_id = $2", []any{p.From, demoted}, 0},
+		{"spender rejects", "INSERT INTO transfer_approvals (pending_transfer_id, user_id, decision) VALUES ($1, $2, 'reject')", []any{p.ID, spender}, 0},
+		{"owner approves", "INSERT INTO transfer_approvals (pending_transfer_id, user_id, decision) VALUES ($1, $2, 'approve')", []any{p.ID, owner}, 1},
+	}
+	for _, s := range steps {
+		mustExec(t, tx, s.query, s.args...)
+		got, err := countApprovalsTx(tx, ctx, &p)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got != s.want {
+			t.Fatalf("%s: approvals = %d, want %d", s.name, got, s.want)
+		}
+	}
+}
+
+func mustExec(t *testing.T, tx pgx.Tx, query string, args ...any) {
+	t.Helper()
+	if _, err := tx.Exec(context.Background(), query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
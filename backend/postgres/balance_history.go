@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type BalancePoint struct {
+	Start         time.Time
+	At            time.Time
+	AmountCents   int64
+	IncomingCents int64
+	OutgoingCents int64
+}
+
+// GetBalanceAt returns the balance after all transactions made up to and including at.
+func GetBalanceAt(db *pgkit.DB, ctx context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	var amount int64
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(s.amount_cents, 0) + COALESCE((
+			SELECT sum(CASE WHEN t.to_user_id = $1 THEN t.amount_cents ELSE 0 END)
+				- sum(CASE WHEN t.from_user_id = $1 THEN t.amount_cents ELSE 0 END)
+			FROM transactions t
+			WHERE (t.from_user_id = $1 OR t.to_user_id = $1) AND t.deleted_at IS NULL
+				AND t.inserted_at >= COALESCE(s.taken_at, '-infinity') AND t.inserted_at <= $2
+		), 0)
+		FROM (SELECT 1) one
+		LEFT JOIN LATERAL (
+			SELECT taken_at, amount_cents FROM balance_snapshots
+			WHERE user_id = $1 AND taken_at <= $2
+			ORDER BY taken_at DESC
+			LIMIT 1
+		) s ON true
+	`, userID, at).Scan(&amount)
+	return amount, err
+}
+
+// GetBalanceHistory returns the opening balance at from and one closing balance per granularity bucket up to to.
+// Granularity is a date_trunc unit: day, week or month. Buckets start at UTC midnight like snapshots and reports.
+func GetBalanceHistory(db *pgkit.DB, ctx context.Context, userID uuid.UUID, from, to time.Time, granularity string) (int64, []BalancePoint, error) {
+	opening, err := GetBalanceAt(db, ctx, userID, from)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT b.start AT TIME ZONE 'UTC',
+			COALESCE(sum(t.amount_cents) FILTER (WHERE t.to_user_id = $1), 0),
+			COALESCE(sum(t.amount_cents) FILTER (WHERE t.from_user_id = $1), 0)
+		FROM generate_series(date_trunc($4, $2::timestamptz AT TIME ZONE 'UTC'), $3::timestamptz AT TIME ZONE 'UTC', ('1 ' || $4)::interval) AS b(start)
+		LEFT JOIN transactions t
+			ON (t.from_user_id = $1 OR t.to_user_id = $1) AND t.deleted_at IS NULL
+			AND t.inserted_at > $2 AND t.inserted_at <= $3
+			AND t.inserted_at >= b.start AT TIME ZONE 'UTC' AND t.inserted_at < (b.start + ('1 ' || $4)::interval) AT TIME ZONE 'UTC'
+		GROUP BY b.start
+		ORDER BY b.start
+	`, userID, from, to, granularity)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var points []BalancePoint
+	amount := opening
+	for rows.Next() {
+		var p BalancePoint
+		if err := rows.Scan(&p.Start, &p.IncomingCents, &p.OutgoingCents); err != nil {
+			return 0, nil, err
+		}
+		p.Start = p.Start.UTC()
+		amount += p.IncomingCents - p.OutgoingCents
+		p.AmountCents = amount
+		p.At = nextBucket(p.Start, granularity)
+		if p.At.After(to) {
+			p.At = to
+		}
+		points = append(points, p)
+	}
+	return opening, points, rows.Err()
+}
+
+func nextBucket(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// TakeBalanceSnapshots stores balances as of cutoff for users with transactions since the previous snapshot.
+// Users without new transactions keep their older snapshot, which is still exact.
+func TakeBalanceSnapshots(db *pgkit.DB, ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		WITH last AS (
+			SELECT COALESCE(max(taken_at), '-infinity') AS at FROM balance_snapshots
+		), moves AS (
+			SELECT t.to_user_id AS user_id, t.amount_cents AS delta
+			FROM transactions t, last
+			WHERE t.deleted_at IS NULL AND t.inserted_at >= last.at AND t.inserted_at < $1
+			UNION ALL
+			SELECT t.from_user_id, -t.amount_cents
+			FROM transactions t, last
+			WHERE t.deleted_at IS NULL AND t.inserted_at >= last.at AND t.inserted_at < $1
+		)
+		INSERT INTO balance_snapshots (user_id, taken_at, amount_cents)
+		SELECT m.user_id, $1, COALESCE((
+			SELECT s.amount_cents FROM balance_snapshots s
+			WHERE s.user_id = m.user_id
+			ORDER BY s.taken_at DESC
+			LIMIT 1
+		), 0) + sum(m.delta)
+		FROM moves m, last
+		WHERE last.at < $1
+		GROUP BY m.user_id
+		ON CONFLICT (user_id, taken_at) DO NOTHING
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (p *BalancePoint) ToBalancePointFull() schemas.BalancePointFull {
+	return schemas.BalancePointFull{
+		Start:    p.Start.Format(time.RFC3339),
+		At:       p.At.Format(time.RFC3339),
+		Balance:  p.AmountCents,
+		Incoming: p.IncomingCents,
+		Outgoing: p.OutgoingCents,
+	}
+}
@@ -3,3 +3,41 @@ package postgres
 import "errors"
 
 var ErrCantPay = errors.New("user can't pay")
+
+var ErrApiKeyNotFound = errors.New("api key not found")
+
+var ErrApiKeyExpired = errors.New("api key is expired")
+
+var ErrLastOwner = errors.New("account must keep at least one owner")
+
+var ErrTransferNotPending = errors.New("transfer is not pending")
+
+var ErrSelfApproval = errors.New("initiator can't approve own transfer")
+
+var ErrDecisionChanged = errors.New("approver already made a different decision")
+
+var ErrSourceFrozen = errors.New("source account is frozen")
+
+var ErrTargetFrozen = errors.New("target account is frozen")
+
+var ErrAmbiguousUsername = errors.New("username matches several users")
+
+var ErrDuplicateReference = errors.New("external reference is already used")
+
+var ErrPaymentTransition = errors.New("action is not allowed in the payment status")
+
+var ErrPaymentForbidden = errors.New("action is not allowed for the user's payment role")
+
+var ErrOverpayment = errors.New("amount exceeds the remaining sum")
+
+var ErrAmountOutOfRange = errors.New("amount is outside of the allowed range")
+
+var ErrMandateExists = errors.New("payer already has an active mandate for the payee")
+
+var ErrNoMandate = errors.New("no active mandate for the payment")
+
+var ErrMandateLimit = errors.New("payment exceeds the mandate limit")
+
+var ErrMoneyRequestBlocked = errors.New("payer blocked money requests from the user")
+
+var ErrTooManyMoneyRequests = errors.New("too many open money requests")
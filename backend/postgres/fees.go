@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// FeeAccountID is the system account collecting fees.
+var FeeAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+const feeDescription = "Fee"
+
+type FeeRule struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+
+	Name            string
+	TransactionType schemas.TransactionType
+	AccountKind     schemas.AccountKind
+	MinAmountCents  int64
+	MaxAmountCents  *int64
+	Model           schemas.FeeModel
+	FlatCents       int64
+	PercentBps      int64
+	Tiers           []schemas.FeeTier
+	MinFeeCents     int64
+	MaxFeeCents     *int64
+	Payer           schemas.FeePayer
+	Priority        int
+	CreatedBy       uuid.UUID
+}
+
+// FeeQuote is the fee a transfer would be charged, Rule is nil when no rule matches.
+type FeeQuote struct {
+	AmountCents int64
+	FeeCents    int64
+	Rule        *FeeRule
+}
+
+const feeRuleColumns = "id, inserted_at, updated_at, name, transaction_type, COALESCE(account_kind, ''), min_amount, max_amount, model, flat_cents, percent_bps, tiers, min_fee, max_fee, payer, priority, created_by"
+
+func scanFeeRule(row pgx.Row, r *FeeRule) error {
+	return row.Scan(&r.ID, &r.InsertedAt, &r.UpdatedAt, &r.Name, &r.TransactionType, &r.AccountKind, &r.MinAmountCents, &r.MaxAmountCents, &r.Model, &r.FlatCents, &r.PercentBps, &r.Tiers, &r.MinFeeCents, &r.MaxFeeCents, &r.Payer, &r.Priority, &r.CreatedBy)
+}
+
+func (r *FeeRule) ToFeeRuleFull() schemas.FeeRuleFull {
+	return schemas.FeeRuleFull{
+		ID:              r.ID.String(),
+		CreatedAt:       r.InsertedAt.Format(time.RFC3339),
+		Name:            r.Name,
+		TransactionType: r.TransactionType,
+		AccountKind:     r.AccountKind,
+		MinAmount:       r.MinAmountCents,
+		MaxAmount:       r.MaxAmountCents,
+		Model:           r.Model,
+		Flat:            r.FlatCents,
+		PercentBps:      r.PercentBps,
+		Tiers:           r.Tiers,
+		MinFee:          r.MinFeeCents,
+		MaxFee:          r.MaxFeeCents,
+		Payer:           r.Payer,
+		Priority:        r.Priority,
+	}
+}
+
+// Matches reports whether amountCents falls into the rule's amount range.
+func (r *FeeRule) Matches(amountCents int64) bool {
+	return amountCents >= r.MinAmountCents && (r.MaxAmountCents == nil || amountCents <= *r.MaxAmountCents)
+}
+
+// Fee computes the fee for amountCents. Percentages are in basis points rounded half up,
+// tiered rules use the first tier covering the amount. The result is clamped to the
+// min/max caps and a fee paid by the target never exceeds what it receives.
+func (r *FeeRule) Fee(amountCents int64) int64 {
+	var fee int64
+	switch r.Model {
+	case schemas.FeeFlat:
+		fee = r.FlatCents
+	case schemas.FeePercentage:
+		fee = percentOf(amountCents, r.PercentBps)
+	case schemas.FeeTiered:
+		for _, t := range r.Tiers {
+			if t.UpTo == nil || amountCents <= *t.UpTo {
+				fee = t.Flat + percentOf(amountCents, t.PercentBps)
+				break
+			}
+		}
+	}
+
+	fee = max(fee, r.MinFeeCents)
+	if r.MaxFeeCents != nil {
+		fee = min(fee, *r.MaxFeeCents)
+	}
+	if r.Payer == schemas.FeePayerTarget {
+		fee = min(fee, amountCents)
+	}
+	return max(fee, 0)
+}
+
+// percentOf splits the amount into whole and partial 10000s, so amountCents*bps can't overflow
+// for rates up to 100%.
+func percentOf(amountCents, bps int64) int64 {
+	return amountCents/10000*bps + (amountCents%10000*bps+5000)/10000
+}
+
+func ListFeeRules(db *pgkit.DB, ctx context.Context) ([]FeeRule, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+feeRuleColumns+" FROM fee_rules WHERE deleted_at IS NULL ORDER BY transaction_type, priority DESC, inserted_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []FeeRule
+	for rows.Next() {
+		var r FeeRule
+		if err := scanFeeRule(rows, &r); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func CreateFeeRule(db *pgkit.DB, ctx context.Context, r *FeeRule) error {
+	if r.Tiers == nil {
+		r.Tiers = []schemas.FeeTier{}
+	}
+	if r.Payer == "" {
+		r.Payer = schemas.FeePayerSource
+	}
+	return db.Pool.QueryRow(ctx, `
+		INSERT INTO fee_rules (name, transaction_type, account_kind, min_amount, max_amount, model, flat_cents, percent_bps, tiers, min_fee, max_fee, payer, priority, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, inserted_at, updated_at
+	`, r.Name, r.TransactionType, r.AccountKind, r.MinAmountCents, r.MaxAmountCents, r.Model, r.FlatCents, r.PercentBps, r.Tiers, r.MinFeeCents, r.MaxFeeCents, r.Payer, r.Priority, r.CreatedBy).
+		Scan(&r.ID, &r.InsertedAt, &r.UpdatedAt)
+}
+
+func DeleteFeeRule(db *pgkit.DB, ctx context.Context, id uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "UPDATE fee_rules SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// QuoteFee previews the fee makeTransactionTx would charge for the transfer, moving money
+// within the same account is free.
+func QuoteFee(db *pgkit.DB, ctx context.Context, transactionType schemas.TransactionType, from, to uuid.UUID, amountCents int64) (*FeeQuote, error) {
+	if from == to {
+		return &FeeQuote{AmountCents: amountCents}, nil
+	}
+
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	return quoteFeeTx(tx, ctx, transactionType, to, amountCents)
+}
+
+// quoteFeeTx picks the highest priority rule for the type, the target's account kind and the amount.
+// Targets without an account row yet are personal.
+func quoteFeeTx(tx pgx.Tx, ctx context.Context, transactionType schemas.TransactionType, to uuid.UUID, amountCents int64) (*FeeQuote, error) {
+	quote := FeeQuote{AmountCents: amountCents}
+	if !chargesFee(transactionType) || to == FeeAccountID {
+		return &quote, nil
+	}
+
+	rows, err := tx.Query(ctx, "SELECT "+feeRuleColumns+` FROM fee_rules
+		WHERE deleted_at IS NULL AND transaction_type = $1
+			AND (account_kind IS NULL OR account_kind = COALESCE((SELECT kind FROM accounts WHERE id = $2), 'personal'))
+		ORDER BY priority DESC, inserted_at`, transactionType, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r FeeRule
+		if err := scanFeeRule(rows, &r); err != nil {
+			return nil, err
+		}
+		if r.Matches(amountCents) {
+			quote.Rule = &r
+			quote.FeeCents = r.Fee(amountCents)
+			break
+		}
+	}
+	return &quote, rows.Err()
+}
+
+// chargesFee reports whether transactions of the type can carry a fee, money supply operations never do.
+func chargesFee(t schemas.TransactionType) bool {
+	switch t {
+	case schemas.TransactionMint, schemas.TransactionBurn, schemas.TransactionFee, schemas.TransactionAdjustment:
+		return false
+	}
+	return true
+}
+
+// Payer returns the side the quoted fee is taken from.
+func (q *FeeQuote) Payer() schemas.FeePayer {
+	if q.Rule == nil {
+		return ""
+	}
+	return q.Rule.Payer
+}
+
+func (q *FeeQuote) ToFeeQuoteFull() schemas.FeeQuoteFull {
+	full := schemas.FeeQuoteFull{
+		Amount: q.AmountCents,
+		Fee:    q.FeeCents,
+		Payer:  q.Payer(),
+		Debit:  q.AmountCents,
+		Credit: q.AmountCents,
+	}
+	if q.Payer() == schemas.FeePayerTarget {
+		full.Credit -= q.FeeCents
+	} else {
+		full.Debit += q.FeeCents
+	}
+	if q.Rule != nil {
+		full.FeeRuleID = q.Rule.ID.String()
+	}
+	return full
+}
+
+// chargeFeeTx books the fee as a fee transaction linked to the parent, freezes don't block it
+// since the parent transfer already passed the checks.
+func chargeFeeTx(tx pgx.Tx, ctx context.Context, parent *Transaction, quote *FeeQuote) error {
+	payer := parent.From
+	if quote.Payer() == schemas.FeePayerTarget {
+		payer = parent.To
+	}
+	fee := Transaction{
+		From:        payer,
+		To:          FeeAccountID,
+		Initiator:   parent.Initiator,
+		AmountCents: quote.FeeCents,
+		Description: feeDescription,
+		Type:        schemas.TransactionFee,
+		ParentID:    &parent.LineID,
+	}
+	if err := insertTransactionTx(tx, ctx, &fee); err != nil {
+		return err
+	}
+	return updateBalancesTx(tx, ctx, payer, FeeAccountID, quote.FeeCents)
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"math"
+	"testing"
+
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func cents(v int64) *int64 {
+	return &v
+}
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		bps    int64
+		want   int64
+	}{
+		{"zero rate", 10000, 0, 0},
+		{"one percent", 10000, 100, 100},
+		{"rounds down below half", 149, 100, 1},
+		{"rounds half up", 150, 100, 2},
+		{"tiny amount rounds to zero", 49, 100, 0},
+		{"full amount", 1234, 10000, 1234},
+		{"huge amount does not overflow", math.MaxInt64, 10000, math.MaxInt64},
+		{"huge amount rounds half up", 1e15 + 50, 100, 1e13 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentOf(tt.amount, tt.bps); got != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFeeRuleFee(t *testing.T) {
+	tiers := []schemas.FeeTier{
+		{UpTo: cents(1000), Flat: 10},
+		{UpTo: cents(10000), Flat: 5, PercentBps: 100},
+		{PercentBps: 50},
+	}
+
+	tests := []struct {
+		name   string
+		rule   FeeRule
+		amount int64
+		want   int64
+	}{
+		{"flat", FeeRule{Model: schemas.FeeFlat, FlatCents: 30}, 5000, 30},
+		{"percentage", FeeRule{Model: schemas.FeePercentage, PercentBps: 250}, 10000, 250},
+		{"percentage rounds half up", FeeRule{Model: schemas.FeePercentage, PercentBps: 100}, 250, 3},
+		{"first tier", FeeRule{Model: schemas.FeeTiered, Tiers: tiers}, 500, 10},
+		{"tier bound is inclusive", FeeRule{Model: schemas.FeeTiered, Tiers: tiers}, 1000, 10},
+		{"second tier", FeeRule{Model: schemas.FeeTiered, Tiers: tiers}, 5000, 55},
+		{"open ended tier", FeeRule{Model: schemas.FeeTiered, Tiers: tiers}, 100000, 500},
+		{"no tier covers amount", FeeRule{Model: schemas.FeeTiered, Tiers: tiers[:1]}, 5000, 0},
+		{"min fee", FeeRule{Model: schemas.FeePercentage, PercentBps: 100, MinFeeCents: 25}, 1000, 25},
+		{"max fee", FeeRule{Model: schemas.FeePercentage, PercentBps: 100, MaxFeeCents: cents(500)}, 100000, 500},
+		{"max fee wins over min fee", FeeRule{Model: schemas.FeeFlat, MinFeeCents: 50, MaxFeeCents: cents(20)}, 1000, 20},
+		{"target fee capped at amount", FeeRule{Model: schemas.FeeFlat, FlatCents: 300, Payer: schemas.FeePayerTarget}, 200, 200},
+		{"source fee not capped at amount", FeeRule{Model: schemas.FeeFlat, FlatCents: 300, Payer: schemas.FeePayerSource}, 200, 300},
+		{"percentage of a huge amount", FeeRule{Model: schemas.FeePercentage, PercentBps: 10000}, 1e15, 1e15},
+		{"negative fee clamped to zero", FeeRule{Model: schemas.FeeFlat, FlatCents: -10}, 1000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Fee(tt.amount); got != tt.want {
+				t.Fatalf("expected fee %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFeeRuleMatches(t *testing.T) {
+	rule := FeeRule{MinAmountCents: 100, MaxAmountCents: cents(1000)}
+
+	tests := []struct {
+		name   string
+		rule   FeeRule
+		amount int64
+		want   bool
+	}{
+		{"below min", rule, 99, false},
+		{"min is inclusive", rule, 100, true},
+		{"max is inclusive", rule, 1000, true},
+		{"above max", rule, 1001, false},
+		{"no max", FeeRule{MinAmountCents: 100}, 1 << 40, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.amount); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
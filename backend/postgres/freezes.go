@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type AccountFreeze struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+
+	AccountID uuid.UUID
+	Direction schemas.FreezeDirection
+	Reason    string
+	ExpiresAt *time.Time
+	Creator   uuid.UUID
+	LiftedBy  *uuid.UUID
+}
+
+const accountFreezeColumns = "id, inserted_at, updated_at, deleted_at, account_id, direction, reason, expires_at, created_by, lifted_by"
+
+func scanAccountFreeze(row pgx.Row, f *AccountFreeze) error {
+	return row.Scan(&f.ID, &f.InsertedAt, &f.UpdatedAt, &f.DeletedAt, &f.AccountID, &f.Direction, &f.Reason, &f.ExpiresAt, &f.Creator, &f.LiftedBy)
+}
+
+func (f *AccountFreeze) ToAccountFreezeFull() schemas.AccountFreezeFull {
+	full := schemas.AccountFreezeFull{
+		ID:        f.ID.String(),
+		AccountID: f.AccountID.String(),
+		CreatedAt: f.InsertedAt.Format(time.RFC3339),
+		Direction: f.Direction,
+		Reason:    f.Reason,
+		CreatedBy: f.Creator.String(),
+	}
+	if f.ExpiresAt != nil {
+		full.ExpiresAt = f.ExpiresAt.Format(time.RFC3339)
+	}
+	if f.DeletedAt != nil {
+		full.LiftedAt = f.DeletedAt.Format(time.RFC3339)
+	}
+	if f.LiftedBy != nil {
+		full.LiftedBy = f.LiftedBy.String()
+	}
+	return full
+}
+
+// Blocks reports whether the freeze stops money moving in the given direction.
+func (f *AccountFreeze) Blocks(direction schemas.FreezeDirection) bool {
+	return f.Direction == schemas.FreezeBoth || f.Direction == direction
+}
+
+// FreezeAccount replaces the active freeze of the account, if any.
+func FreezeAccount(db *pgkit.DB, ctx context.Context, f *AccountFreeze) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, "UPDATE account_freezes SET deleted_at = now(), lifted_by = $2 WHERE account_id = $1 AND deleted_at IS NULL", f.AccountID, f.Creator); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO account_freezes (account_id, direction, reason, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, inserted_at, updated_at
+	`, f.AccountID, f.Direction, f.Reason, f.ExpiresAt, f.Creator).Scan(&f.ID, &f.InsertedAt, &f.UpdatedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func UnfreezeAccount(db *pgkit.DB, ctx context.Context, accountID uuid.UUID, liftedBy uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "UPDATE account_freezes SET deleted_at = now(), lifted_by = $2 WHERE account_id = $1 AND deleted_at IS NULL", accountID, liftedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetActiveFreeze returns pgx.ErrNoRows when the account is not frozen or the freeze has expired.
+func GetActiveFreeze(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) (*AccountFreeze, error) {
+	var f AccountFreeze
+	err := scanAccountFreeze(db.Pool.QueryRow(ctx, "SELECT "+accountFreezeColumns+" FROM account_freezes WHERE account_id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > now())", accountID), &f)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func ListAccountFreezes(db *pgkit.DB, ctx context.Context, accountID uuid.UUID) ([]AccountFreeze, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+accountFreezeColumns+" FROM account_freezes WHERE account_id = $1 ORDER BY inserted_at DESC", accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var freezes []AccountFreeze
+	for rows.Next() {
+		var f AccountFreeze
+		if err := scanAccountFreeze(rows, &f); err != nil {
+			return nil, err
+		}
+		freezes = append(freezes, f)
+	}
+	return freezes, rows.Err()
+}
+
+func checkFreezesTx(tx pgx.Tx, ctx context.Context, from uuid.UUID, to uuid.UUID) error {
+	rows, err := tx.Query(ctx, "SELECT "+accountFreezeColumns+" FROM account_freezes WHERE account_id = ANY($1) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > now())", []uuid.UUID{from, to})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f AccountFreeze
+		if err := scanAccountFreeze(rows, &f); err != nil {
+			return err
+		}
+		if f.AccountID == from && f.Blocks(schemas.FreezeOutgoing) {
+			return ErrSourceFrozen
+		}
+		if f.AccountID == to && f.Blocks(schemas.FreezeIncoming) {
+			return ErrTargetFrozen
+		}
+	}
+	return rows.Err()
+}
+
+// SyncFreezes makes the accounts in ids the only ones frozen with reason by actor: missing freezes
+// are created and freezes of accounts no longer listed are lifted. Manual freezes are left alone.
+func SyncFreezes(db *pgkit.DB, ctx context.Context, ids []uuid.UUID, reason string, actor uuid.UUID) (frozen int64, lifted int64, err error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Expired freezes still hold the unique slot, release them first
+	if _, err := tx.Exec(ctx, "UPDATE account_freezes SET deleted_at = now() WHERE account_id = ANY($1) AND deleted_at IS NULL AND expires_at <= now()", ids); err != nil {
+		return 0, 0, err
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO account_freezes (account_id, direction, reason, created_by)
+		SELECT id, $2, $3, $4 FROM unnest($1::uuid[]) AS id
+		ON CONFLICT (account_id) WHERE deleted_at IS NULL DO NOTHING
+	`, ids, schemas.FreezeBoth, reason, actor)
+	if err != nil {
+		return 0, 0, err
+	}
+	frozen = tag.RowsAffected()
+
+	tag, err = tx.Exec(ctx, `
+		UPDATE account_freezes SET deleted_at = now(), lifted_by = $3
+		WHERE deleted_at IS NULL AND created_by = $3 AND reason = $2 AND NOT (account_id = ANY($1))
+	`, ids, reason, actor)
+	if err != nil {
+		return 0, 0, err
+	}
+	lifted = tag.RowsAffected()
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+	committed = true
+	return frozen, lifted, nil
+}
@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type Mandate struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+	RevokedAt  *time.Time
+
+	Payer       uuid.UUID
+	Payee       uuid.UUID
+	LimitCents  int64
+	Period      schemas.MandatePeriod
+	Description string
+	ExpiresAt   *time.Time
+
+	// UsedCents is collected in the current calendar period
+	UsedCents int64
+}
+
+type MandateDebit struct {
+	ID            uuid.UUID
+	InsertedAt    time.Time
+	MandateID     uuid.UUID
+	PaymentID     uuid.UUID
+	TransactionID uuid.UUID
+	AmountCents   int64
+}
+
+const mandateColumns = `m.id, m.inserted_at, m.updated_at, m.revoked_at, m.payer_id, m.payee_id, m.limit_cents, m.period, COALESCE(m.description, ''), m.expires_at,
+	COALESCE((SELECT sum(d.amount_cents) FROM mandate_debits d WHERE d.mandate_id = m.id AND d.inserted_at >= date_trunc(m.period, now())), 0)::bigint`
+
+func scanMandate(row pgx.Row, m *Mandate) error {
+	return row.Scan(&m.ID, &m.InsertedAt, &m.UpdatedAt, &m.RevokedAt, &m.Payer, &m.Payee, &m.LimitCents, &m.Period, &m.Description, &m.ExpiresAt, &m.UsedCents)
+}
+
+func (m *Mandate) ToMandateFull() schemas.MandateFull {
+	full := schemas.MandateFull{
+		ID:          m.ID.String(),
+		CreatedAt:   m.InsertedAt.Format(time.RFC3339),
+		Payer:       m.Payer.String(),
+		Payee:       m.Payee.String(),
+		Limit:       m.LimitCents,
+		Period:      m.Period,
+		Used:        m.UsedCents,
+		Description: m.Description,
+	}
+	if m.ExpiresAt != nil {
+		full.ExpiresAt = m.ExpiresAt.Format(time.RFC3339)
+	}
+	if m.RevokedAt != nil {
+		full.RevokedAt = m.RevokedAt.Format(time.RFC3339)
+	}
+	return full
+}
+
+func (d *MandateDebit) ToMandateDebitFull() schemas.MandateDebitFull {
+	return schemas.MandateDebitFull{
+		ID:            d.ID.String(),
+		CreatedAt:     d.InsertedAt.Format(time.RFC3339),
+		PaymentID:     d.PaymentID.String(),
+		TransactionID: d.TransactionID.String(),
+		Amount:        d.AmountCents,
+	}
+}
+
+// Covers reports whether amountCents still fits into the limit of the current period.
+func (m *Mandate) Covers(amountCents int64) bool {
+	return m.UsedCents+amountCents <= m.LimitCents
+}
+
+// CreateMandate fails with ErrMandateExists while the payer has an active mandate for the payee,
+// an expired one is closed as revoked at its expiry and frees the slot.
+func CreateMandate(db *pgkit.DB, ctx context.Context, m *Mandate) error {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE mandates SET revoked_at = expires_at
+		WHERE payer_id = $1 AND payee_id = $2 AND revoked_at IS NULL AND expires_at <= now()
+	`, m.Payer, m.Payee); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO mandates (payer_id, payee_id, limit_cents, period, description, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, inserted_at, updated_at
+	`, m.Payer, m.Payee, m.LimitCents, m.Period, m.Description, m.ExpiresAt).Scan(&m.ID, &m.InsertedAt, &m.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "mandates_active_idx" {
+		return ErrMandateExists
+	} else if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// ListMandates returns mandates the user gave or received, an empty role matches both.
+func ListMandates(db *pgkit.DB, ctx context.Context, userID uuid.UUID, role schemas.PaymentRole, includeRevoked bool) ([]Mandate, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+mandateColumns+` FROM mandates m
+		WHERE (($2 IN ('', 'payer') AND m.payer_id = $1) OR ($2 IN ('', 'payee') AND m.payee_id = $1))
+			AND ($3 OR m.revoked_at IS NULL)
+		ORDER BY m.inserted_at DESC`, userID, role, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mandates []Mandate
+	for rows.Next() {
+		var m Mandate
+		if err := scanMandate(rows, &m); err != nil {
+			return nil, err
+		}
+		mandates = append(mandates, m)
+	}
+	return mandates, rows.Err()
+}
+
+// GetMandate returns the mandate to its payer or payee.
+func GetMandate(db *pgkit.DB, ctx context.Context, id uuid.UUID, userID uuid.UUID) (*Mandate, error) {
+	var m Mandate
+	if err := scanMandate(db.Pool.QueryRow(ctx, "SELECT "+mandateColumns+" FROM mandates m WHERE m.id = $1 AND (m.payer_id = $2 OR m.payee_id = $2)", id, userID), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// RevokeMandate stops further debits, only the payer can revoke.
+func RevokeMandate(db *pgkit.DB, ctx context.Context, id uuid.UUID, payerID uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "UPDATE mandates SET revoked_at = now() WHERE id = $1 AND payer_id = $2 AND revoked_at IS NULL", id, payerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func ListMandateDebits(db *pgkit.DB, ctx context.Context, mandateID uuid.UUID) ([]MandateDebit, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT id, inserted_at, mandate_id, payment_id, transaction_id, amount_cents FROM mandate_debits WHERE mandate_id = $1 ORDER BY inserted_at DESC", mandateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debits []MandateDebit
+	for rows.Next() {
+		var d MandateDebit
+		if err := rows.Scan(&d.ID, &d.InsertedAt, &d.MandateID, &d.PaymentID, &d.TransactionID, &d.AmountCents); err != nil {
+			return nil, err
+		}
+		debits = append(debits, d)
+	}
+	return debits, rows.Err()
+}
+
+// AutoPayPayment pays a new payment on behalf of its payer when an active mandate for the payee
+// covers the whole amount together with the fee the payer is charged. Only payments created by
+// the payee itself or an owner or spender of the payee account are debited. It fails with
+// ErrNoMandate or ErrMandateLimit when the payment has to be paid by hand, and with the usual
+// transaction errors when the payer can't pay.
+func AutoPayPayment(db *pgkit.DB, ctx context.Context, paymentID uuid.UUID) (*Payment, error) {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var p Payment
+	if err := scanPayment(tx.QueryRow(ctx, "SELECT "+paymentColumns+" FROM payments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", paymentID), &p); err != nil {
+		return nil, err
+	}
+	if p.IsOpen() {
+		return nil, ErrNoMandate
+	}
+	if p.Creator != p.To {
+		var billsForPayee bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM account_members m
+				JOIN accounts a ON a.id = m.account_id
+				WHERE m.account_id = $1 AND m.user_id = $2 AND m.role IN ($3, $4) AND a.deleted_at IS NULL
+			)`, p.To, p.Creator, schemas.RoleOwner, schemas.RoleSpender).Scan(&billsForPayee); err != nil {
+			return nil, err
+		}
+		if !billsForPayee {
+			return nil, ErrNoMandate
+		}
+	}
+
+	var m Mandate
+	err = scanMandate(tx.QueryRow(ctx, "SELECT "+mandateColumns+` FROM mandates m
+		WHERE m.payer_id = $1 AND m.payee_id = $2 AND m.revoked_at IS NULL AND (m.expires_at IS NULL OR m.expires_at > now())
+		FOR UPDATE`, *p.From, p.To), &m)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNoMandate
+	} else if err != nil {
+		return nil, err
+	}
+
+	amount := p.Remaining()
+	debit := amount
+	if *p.From != p.To {
+		quote, err := quoteFeeTx(tx, ctx, schemas.TransactionPayment, p.To, amount)
+		if err != nil {
+			return nil, err
+		}
+		if quote.Payer() == schemas.FeePayerSource {
+			debit += quote.FeeCents
+		}
+	}
+	if !m.Covers(debit) {
+		return nil, ErrMandateLimit
+	}
+
+	transaction, err := payPaymentTx(tx, ctx, &p, *p.From, amount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO mandate_debits (mandate_id, payment_id, transaction_id, amount_cents) VALUES ($1, $2, $3, $4)",
+		m.ID, p.ID, transaction.LineID, debit); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE payments SET mandate_id = $2 WHERE id = $1", p.ID, m.ID); err != nil {
+		return nil, err
+	}
+	p.MandateID = &m.ID
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return &p, nil
+}
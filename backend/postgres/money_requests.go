@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type MoneyRequestBlock struct {
+	UserID     uuid.UUID
+	BlockedID  uuid.UUID
+	InsertedAt time.Time
+	Mode       schemas.MoneyRequestBlockMode
+}
+
+func (b *MoneyRequestBlock) ToMoneyRequestBlockFull() schemas.MoneyRequestBlockFull {
+	return schemas.MoneyRequestBlockFull{
+		UserID:    b.BlockedID.String(),
+		Mode:      b.Mode,
+		CreatedAt: b.InsertedAt.Format(time.RFC3339),
+	}
+}
+
+type Notification struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	ReadAt     *time.Time
+	UserID     uuid.UUID
+	Kind       schemas.NotificationKind
+	PaymentID  *uuid.UUID
+}
+
+func (n *Notification) ToNotificationFull() schemas.NotificationFull {
+	full := schemas.NotificationFull{
+		ID:        n.ID.String(),
+		CreatedAt: n.InsertedAt.Format(time.RFC3339),
+		Kind:      n.Kind,
+	}
+	if n.PaymentID != nil {
+		full.PaymentID = n.PaymentID.String()
+	}
+	if n.ReadAt != nil {
+		full.ReadAt = n.ReadAt.Format(time.RFC3339)
+	}
+	return full
+}
+
+// CreateMoneyRequest stores a peer request from p.Creator to p.From and notifies the payer unless
+// the requester is muted. It fails with ErrMoneyRequestBlocked when the payer blocked the requester
+// and with ErrTooManyMoneyRequests when the requester already has maxOpen unpaid requests.
+func CreateMoneyRequest(db *pgkit.DB, ctx context.Context, p *Payment, maxOpen int) error {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Serializes requests of one user so concurrent ones can't get past the limit together
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))", p.Creator); err != nil {
+		return err
+	}
+
+	var mode *schemas.MoneyRequestBlockMode
+	if err := tx.QueryRow(ctx, "SELECT (SELECT mode FROM money_request_blocks WHERE user_id = $1 AND blocked_id = $2)", *p.From, p.Creator).Scan(&mode); err != nil {
+		return err
+	}
+	if mode != nil && *mode == schemas.MoneyRequestBlock {
+		return ErrMoneyRequestBlocked
+	}
+
+	var open int
+	if err := tx.QueryRow(ctx, "SELECT count(*) FROM payments WHERE creator_id = $1 AND peer AND deleted_at IS NULL AND status IN ($2, $3)",
+		p.Creator, schemas.StatusPending, schemas.StatusPartiallyPaid).Scan(&open); err != nil {
+		return err
+	}
+	if open >= maxOpen {
+		return ErrTooManyMoneyRequests
+	}
+
+	p.Peer = true
+	if err := insertPaymentTx(tx, ctx, p); err != nil {
+		return err
+	}
+	if mode == nil {
+		if _, err := tx.Exec(ctx, "INSERT INTO notifications (user_id, kind, payment_id) VALUES ($1, $2, $3)", *p.From, schemas.NotificationPaymentRequest, p.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
+
+// SendPaymentReminders notifies payers of unpaid peer requests once every interval, at most
+// maxReminders times per request. Muted and blocked requesters are skipped.
+func SendPaymentReminders(db *pgkit.DB, ctx context.Context, interval time.Duration, maxReminders int) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		WITH due AS (
+			UPDATE payments p SET reminders_sent = p.reminders_sent + 1, reminded_at = now()
+			WHERE p.peer AND p.deleted_at IS NULL AND p.status IN ($3, $4)
+				AND p.reminders_sent < $2
+				AND COALESCE(p.reminded_at, p.inserted_at) <= now() - make_interval(secs => $1)
+				AND NOT EXISTS (SELECT 1 FROM money_request_blocks b WHERE b.user_id = p.from_id AND b.blocked_id = p.creator_id)
+			RETURNING p.id, p.from_id
+		)
+		INSERT INTO notifications (user_id, kind, payment_id)
+		SELECT from_id, $5, id FROM due
+	`, interval.Seconds(), maxReminders, schemas.StatusPending, schemas.StatusPartiallyPaid, schemas.NotificationPaymentReminder)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func SetMoneyRequestBlock(db *pgkit.DB, ctx context.Context, b *MoneyRequestBlock) error {
+	return db.Pool.QueryRow(ctx, `
+		INSERT INTO money_request_blocks (user_id, blocked_id, mode)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, blocked_id) DO UPDATE SET mode = EXCLUDED.mode
+		RETURNING inserted_at
+	`, b.UserID, b.BlockedID, b.Mode).Scan(&b.InsertedAt)
+}
+
+func DeleteMoneyRequestBlock(db *pgkit.DB, ctx context.Context, userID uuid.UUID, blockedID uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, "DELETE FROM money_request_blocks WHERE user_id = $1 AND blocked_id = $2", userID, blockedID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func ListMoneyRequestBlocks(db *pgkit.DB, ctx context.Context, userID uuid.UUID) ([]MoneyRequestBlock, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT user_id, blocked_id, inserted_at, mode FROM money_request_blocks WHERE user_id = $1 ORDER BY inserted_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []MoneyRequestBlock
+	for rows.Next() {
+		var b MoneyRequestBlock
+		if err := rows.Scan(&b.UserID, &b.BlockedID, &b.InsertedAt, &b.Mode); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// ListNotifications returns the newest notifications of the user first.
+func ListNotifications(db *pgkit.DB, ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]Notification, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, inserted_at, read_at, user_id, kind, payment_id
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY inserted_at DESC
+		LIMIT $3
+	`, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, &n.InsertedAt, &n.ReadAt, &n.UserID, &n.Kind, &n.PaymentID); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func MarkNotificationsRead(db *pgkit.DB, ctx context.Context, userID uuid.UUID) error {
+	_, err := db.Pool.Exec(ctx, "UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL", userID)
+	return err
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// paymentTransition lists the statuses an action applies to and the roles allowed to take it.
+type paymentTransition struct {
+	from  []schemas.PaymentStatus
+	roles []schemas.PaymentRole
+	to    schemas.PaymentStatus
+}
+
+// paymentTransitions is the payment state machine. Paying has no fixed target, it depends
+// on the amount paid so far and is worked out by PayPayment. COMPLETED, CANCELLED and
+// DECLINED are final.
+var paymentTransitions = map[schemas.PaymentAction]paymentTransition{
+	schemas.PaymentActionPay: {
+		from:  []schemas.PaymentStatus{schemas.StatusPending, schemas.StatusPartiallyPaid},
+		roles: []schemas.PaymentRole{schemas.PaymentRolePayer},
+	},
+	schemas.PaymentActionDecline: {
+		from:  []schemas.PaymentStatus{schemas.StatusPending, schemas.StatusPartiallyPaid},
+		roles: []schemas.PaymentRole{schemas.PaymentRolePayer},
+		to:    schemas.StatusDeclined,
+	},
+	schemas.PaymentActionCancel: {
+		from:  []schemas.PaymentStatus{schemas.StatusPending, schemas.StatusPartiallyPaid},
+		roles: []schemas.PaymentRole{schemas.PaymentRolePayee, schemas.PaymentRoleCreator},
+		to:    schemas.StatusCancelled,
+	},
+}
+
+// NextPaymentStatus checks that a user holding roles may take the action in the status and
+// returns the status it leads to. The status is checked first, so a finished payment reports
+// ErrPaymentTransition to everyone.
+func NextPaymentStatus(status schemas.PaymentStatus, roles []schemas.PaymentRole, action schemas.PaymentAction) (schemas.PaymentStatus, error) {
+	t, ok := paymentTransitions[action]
+	if !ok || !slices.Contains(t.from, status) {
+		return "", ErrPaymentTransition
+	}
+	for _, r := range roles {
+		if slices.Contains(t.roles, r) {
+			return t.to, nil
+		}
+	}
+	return "", ErrPaymentForbidden
+}
+
+// Roles returns what the user is to the payment. Anyone but the payee is a payer of an open
+// request, but an open request has nobody to decline it.
+func (p *Payment) Roles(userID uuid.UUID) []schemas.PaymentRole {
+	var roles []schemas.PaymentRole
+	if (p.From != nil && *p.From == userID) || (p.From == nil && p.To != userID) {
+		roles = append(roles, schemas.PaymentRolePayer)
+	}
+	if p.To == userID {
+		roles = append(roles, schemas.PaymentRolePayee)
+	}
+	if p.Creator == userID {
+		roles = append(roles, schemas.PaymentRoleCreator)
+	}
+	return roles
+}
+
+// Next applies NextPaymentStatus to the payment for the user.
+func (p *Payment) Next(userID uuid.UUID, action schemas.PaymentAction) (schemas.PaymentStatus, error) {
+	roles := p.Roles(userID)
+	if action == schemas.PaymentActionDecline && p.IsOpen() {
+		roles = slices.DeleteFunc(roles, func(r schemas.PaymentRole) bool { return r == schemas.PaymentRolePayer })
+	}
+	return NextPaymentStatus(p.Status, roles, action)
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+var (
+	rolePayer   = schemas.PaymentRolePayer
+	rolePayee   = schemas.PaymentRolePayee
+	roleCreator = schemas.PaymentRoleCreator
+)
+
+func TestNextPaymentStatusAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		status schemas.PaymentStatus
+		roles  []schemas.PaymentRole
+		action schemas.PaymentAction
+		want   schemas.PaymentStatus
+	}{
+		{"payer pays unpaid", schemas.StatusPending, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionPay, ""},
+		{"payer pays partially paid", schemas.StatusPartiallyPaid, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionPay, ""},
+		{"payer declines", schemas.StatusPending, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionDecline, schemas.StatusDeclined},
+		{"payer declines partially paid", schemas.StatusPartiallyPaid, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionDecline, schemas.StatusDeclined},
+		{"creator cancels", schemas.StatusPending, []schemas.PaymentRole{roleCreator}, schemas.PaymentActionCancel, schemas.StatusCancelled},
+		{"payee cancels partially paid", schemas.StatusPartiallyPaid, []schemas.PaymentRole{rolePayee}, schemas.PaymentActionCancel, schemas.StatusCancelled},
+		{"payee and creator cancels", schemas.StatusPending, []schemas.PaymentRole{rolePayee, roleCreator}, schemas.PaymentActionCancel, schemas.StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextPaymentStatus(tt.status, tt.roles, tt.action)
+			if err != nil {
+				t.Fatalf("expected transition to be allowed, got %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected status %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextPaymentStatusIllegal(t *testing.T) {
+	tests := []struct {
+		name   string
+		status schemas.PaymentStatus
+		roles  []schemas.PaymentRole
+		action schemas.PaymentAction
+		err    error
+	}{
+		{"pay completed", schemas.StatusCompleted, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionPay, ErrPaymentTransition},
+		{"pay cancelled", schemas.StatusCancelled, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionPay, ErrPaymentTransition},
+		{"pay declined", schemas.StatusDeclined, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionPay, ErrPaymentTransition},
+		{"decline completed", schemas.StatusCompleted, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionDecline, ErrPaymentTransition},
+		{"decline declined", schemas.StatusDeclined, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionDecline, ErrPaymentTransition},
+		{"decline cancelled", schemas.StatusCancelled, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionDecline, ErrPaymentTransition},
+		{"cancel completed", schemas.StatusCompleted, []schemas.PaymentRole{roleCreator}, schemas.PaymentActionCancel, ErrPaymentTransition},
+		{"cancel declined", schemas.StatusDeclined, []schemas.PaymentRole{rolePayee}, schemas.PaymentActionCancel, ErrPaymentTransition},
+		{"cancel cancelled", schemas.StatusCancelled, []schemas.PaymentRole{roleCreator}, schemas.PaymentActionCancel, ErrPaymentTransition},
+		{"unknown action", schemas.StatusPending, []schemas.PaymentRole{rolePayer, rolePayee, roleCreator}, schemas.PaymentAction("refund"), ErrPaymentTransition},
+		{"finished status wins over role", schemas.StatusCompleted, nil, schemas.PaymentActionCancel, ErrPaymentTransition},
+		{"creator pays", schemas.StatusPending, []schemas.PaymentRole{roleCreator}, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"payee pays", schemas.StatusPending, []schemas.PaymentRole{rolePayee}, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"creator declines", schemas.StatusPending, []schemas.PaymentRole{roleCreator}, schemas.PaymentActionDecline, ErrPaymentForbidden},
+		{"payee declines", schemas.StatusPartiallyPaid, []schemas.PaymentRole{rolePayee}, schemas.PaymentActionDecline, ErrPaymentForbidden},
+		{"payer cancels", schemas.StatusPending, []schemas.PaymentRole{rolePayer}, schemas.PaymentActionCancel, ErrPaymentForbidden},
+		{"no role", schemas.StatusPending, nil, schemas.PaymentActionPay, ErrPaymentForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextPaymentStatus(tt.status, tt.roles, tt.action)
+			if err != tt.err {
+				t.Fatalf("expected %v, got status %q and %v", tt.err, got, err)
+			}
+		})
+	}
+}
+
+func TestPaymentRoles(t *testing.T) {
+	from, to, service, stranger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	closed := Payment{From: &from, To: to, Creator: service, Status: schemas.StatusPending}
+	open := Payment{To: to, Creator: to, Status: schemas.StatusPending}
+
+	tests := []struct {
+		name    string
+		payment Payment
+		user    uuid.UUID
+		action  schemas.PaymentAction
+		err     error
+	}{
+		{"payer pays closed", closed, from, schemas.PaymentActionPay, nil},
+		{"creator can't pay for the payer", closed, service, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"payee can't pay closed", closed, to, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"stranger can't pay closed", closed, stranger, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"payer declines closed", closed, from, schemas.PaymentActionDecline, nil},
+		{"creator cancels closed", closed, service, schemas.PaymentActionCancel, nil},
+		{"payer can't cancel closed", closed, from, schemas.PaymentActionCancel, ErrPaymentForbidden},
+		{"anyone pays open", open, stranger, schemas.PaymentActionPay, nil},
+		{"payee can't pay open", open, to, schemas.PaymentActionPay, ErrPaymentForbidden},
+		{"nobody declines open", open, stranger, schemas.PaymentActionDecline, ErrPaymentForbidden},
+		{"stranger can't cancel open", open, stranger, schemas.PaymentActionCancel, ErrPaymentForbidden},
+		{"payee cancels open", open, to, schemas.PaymentActionCancel, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.payment.Next(tt.user, tt.action); err != tt.err {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
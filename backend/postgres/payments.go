@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/nrf24l01/go-web-utils/pgkit"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
@@ -15,49 +16,348 @@ type Payment struct {
 	UpdatedAt  time.Time
 	DeletedAt  time.Time
 
-	From        uuid.UUID
-	To          uuid.UUID
-	Creator     uuid.UUID
-	Amount      int64
-	Status      schemas.PaymentStatus
-	Description string
+	// From is nil for open requests anyone can pay, Amount is 0 when the payer chooses it
+	From         *uuid.UUID
+	To           uuid.UUID
+	Creator      uuid.UUID
+	Amount       int64
+	AmountPaid   int64
+	Status       schemas.PaymentStatus
+	StatusReason string
+	Description  string
+	Category     string
+	Metadata     map[string]any
+
+	MaxUses   *int
+	Uses      int
+	MinAmount *int64
+	MaxAmount *int64
+
+	// MandateID is set when the payment was debited automatically under a mandate
+	MandateID *uuid.UUID
+	// SplitID links the payment to the split bill it is a share of
+	SplitID *uuid.UUID
+	// Peer is set for money requests users send to each other, only they are reminded
+	Peer bool
+
+	Installments []PaymentInstallment
+}
+
+// PaymentInstallment is one part of a payment paid by its own transaction.
+type PaymentInstallment struct {
+	ID            uuid.UUID
+	InsertedAt    time.Time
+	PaymentID     uuid.UUID
+	TransactionID uuid.UUID
+	Payer         uuid.UUID
+	AmountCents   int64
+}
+
+const paymentColumns = "id, from_id, to_id, amount, amount_paid, description, status, COALESCE(status_reason, ''), creator_id, COALESCE(category, ''), metadata, max_uses, uses, min_amount, max_amount, mandate_id, split_id, peer, inserted_at, updated_at"
+
+// paymentVisible lets the payer, the payee and the creator see a payment, open requests are visible to everyone.
+const paymentVisible = "(from_id = $2 OR to_id = $2 OR creator_id = $2 OR from_id IS NULL)"
+
+func scanPayment(row pgx.Row, p *Payment) error {
+	return row.Scan(&p.ID, &p.From, &p.To, &p.Amount, &p.AmountPaid, &p.Description, &p.Status, &p.StatusReason, &p.Creator, &p.Category, &p.Metadata, &p.MaxUses, &p.Uses, &p.MinAmount, &p.MaxAmount, &p.MandateID, &p.SplitID, &p.Peer, &p.InsertedAt, &p.UpdatedAt)
 }
 
 func (p *Payment) ToPaymentFull() schemas.PaymentFull {
-	return schemas.PaymentFull{
-		ID:          p.ID.String(),
-		CreateAt:    p.InsertedAt.Format(time.RFC3339),
-		From:        p.From.String(),
-		To:          p.To.String(),
-		Amount:      p.Amount,
-		Status:      p.Status,
-		Description: p.Description,
+	full := schemas.PaymentFull{
+		ID:           p.ID.String(),
+		CreateAt:     p.InsertedAt.Format(time.RFC3339),
+		To:           p.To.String(),
+		Amount:       p.Amount,
+		AmountPaid:   p.AmountPaid,
+		Status:       p.Status,
+		StatusReason: p.StatusReason,
+		Description:  p.Description,
+		Category:     p.Category,
+		Metadata:     p.Metadata,
+		MaxUses:      p.MaxUses,
+		Uses:         p.Uses,
+		MinAmount:    p.MinAmount,
+		MaxAmount:    p.MaxAmount,
+	}
+	if p.From != nil {
+		full.From = p.From.String()
+	}
+	if p.MandateID != nil {
+		full.MandateID = p.MandateID.String()
+	}
+	if p.SplitID != nil {
+		full.SplitID = p.SplitID.String()
 	}
+	for _, i := range p.Installments {
+		full.Installments = append(full.Installments, schemas.PaymentInstallmentFull{
+			ID:            i.ID.String(),
+			CreatedAt:     i.InsertedAt.Format(time.RFC3339),
+			TransactionID: i.TransactionID.String(),
+			Payer:         i.Payer.String(),
+			Amount:        i.AmountCents,
+		})
+	}
+	return full
+}
+
+// Remaining is the amount still to be paid.
+func (p *Payment) Remaining() int64 {
+	return p.Amount - p.AmountPaid
+}
+
+// IsOpen reports whether anyone can pay the request.
+func (p *Payment) IsOpen() bool {
+	return p.From == nil
+}
+
+// IsParty reports whether the user is the payer, the payee or the creator, only parties see all installments.
+func (p *Payment) IsParty(userID uuid.UUID) bool {
+	return (p.From != nil && *p.From == userID) || p.To == userID || p.Creator == userID
+}
+
+// useAmount picks the amount of one payment of an open request, requested is 0 when the payer didn't choose one.
+func (p *Payment) useAmount(requested int64) (int64, error) {
+	if p.Amount > 0 {
+		if requested != 0 && requested != p.Amount {
+			return 0, ErrAmountOutOfRange
+		}
+		return p.Amount, nil
+	}
+	if requested == 0 || (p.MinAmount != nil && requested < *p.MinAmount) || (p.MaxAmount != nil && requested > *p.MaxAmount) {
+		return 0, ErrAmountOutOfRange
+	}
+	return requested, nil
 }
 
 func (p *Payment) Insert(db *pgkit.DB, ctx context.Context) error {
-	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO payments (from_id, to_id, amount, description, status, creator_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, inserted_at, updated_at
-	`, p.From, p.To, p.Amount, p.Description, p.Status, p.Creator).Scan(&p.ID, &p.InsertedAt, &p.UpdatedAt)
-	return err
+	return db.Pool.QueryRow(ctx, insertPaymentQuery, p.insertArgs()...).Scan(&p.ID, &p.InsertedAt, &p.UpdatedAt)
 }
 
+func insertPaymentTx(tx pgx.Tx, ctx context.Context, p *Payment) error {
+	return tx.QueryRow(ctx, insertPaymentQuery, p.insertArgs()...).Scan(&p.ID, &p.InsertedAt, &p.UpdatedAt)
+}
+
+const insertPaymentQuery = `
+	INSERT INTO payments (from_id, to_id, amount, description, status, creator_id, category, metadata, max_uses, min_amount, max_amount, split_id, peer)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13)
+	RETURNING id, inserted_at, updated_at`
+
+func (p *Payment) insertArgs() []any {
+	metadata := p.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	return []any{p.From, p.To, p.Amount, p.Description, p.Status, p.Creator, p.Category, metadata, p.MaxUses, p.MinAmount, p.MaxAmount, p.SplitID, p.Peer}
+}
+
+// GetPaymentByID returns a payment in any status together with the installments. Users outside
+// of an open request only see their own installments.
 func GetPaymentByID(db *pgkit.DB, ctx context.Context, paymentID uuid.UUID, userID uuid.UUID) (*Payment, error) {
 	var payment Payment
-	err := db.Pool.QueryRow(ctx, `
-		SELECT id, from_id, to_id, amount, description, status, creator_id, inserted_at, updated_at
+	err := scanPayment(db.Pool.QueryRow(ctx, "SELECT "+paymentColumns+`
 		FROM payments
-		WHERE id = $1 AND deleted_at IS NULL AND (from_id = $2 OR to_id = $2 OR creator_id = $2) AND status='UNPAID'
-	`, paymentID, userID).Scan(&payment.ID, &payment.From, &payment.To, &payment.Amount, &payment.Description, &payment.Status, &payment.Creator, &payment.InsertedAt, &payment.UpdatedAt)
+		WHERE id = $1 AND deleted_at IS NULL AND `+paymentVisible, paymentID, userID), &payment)
 	if err != nil {
 		return nil, err
 	}
-	return &payment, nil
+
+	rows, err := db.Pool.Query(ctx, "SELECT id, inserted_at, payment_id, transaction_id, payer_id, amount_cents FROM payment_installments WHERE payment_id = $1 AND ($3 OR payer_id = $2) ORDER BY inserted_at",
+		paymentID, userID, payment.IsParty(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i PaymentInstallment
+		if err := rows.Scan(&i.ID, &i.InsertedAt, &i.PaymentID, &i.TransactionID, &i.Payer, &i.AmountCents); err != nil {
+			return nil, err
+		}
+		payment.Installments = append(payment.Installments, i)
+	}
+	return &payment, rows.Err()
 }
 
-func (p *Payment) ChangeStatus(db *pgkit.DB, ctx context.Context, newStatus schemas.PaymentStatus) error {
-	err := db.Pool.QueryRow(ctx, "UPDATE payments SET status=$1 WHERE id=$2 RETURNING updated_at, status", newStatus, p.ID).Scan(&p.UpdatedAt, &p.Status)
-	return err
+// ChangePaymentStatus takes a decline or cancel action through the payment state machine
+// and stores the new status with the reason.
+func ChangePaymentStatus(db *pgkit.DB, ctx context.Context, paymentID uuid.UUID, userID uuid.UUID, action schemas.PaymentAction, reason string) (*Payment, error) {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var p Payment
+	if err := scanPayment(tx.QueryRow(ctx, "SELECT "+paymentColumns+`
+		FROM payments
+		WHERE id = $1 AND deleted_at IS NULL AND `+paymentVisible+`
+		FOR UPDATE
+	`, paymentID, userID), &p); err != nil {
+		return nil, err
+	}
+	status, err := p.Next(userID, action)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Status, p.StatusReason = status, reason
+	if err := tx.QueryRow(ctx, "UPDATE payments SET status = $2, status_reason = NULLIF($3, '') WHERE id = $1 RETURNING updated_at", p.ID, p.Status, p.StatusReason).Scan(&p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if p.SplitID != nil {
+		if err := syncSplitTx(tx, ctx, *p.SplitID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return &p, nil
+}
+
+// PayPayment pays amountCents of the payment, zero pays the whole remaining sum. The transaction,
+// the installment and the new running total are written in one database transaction, the payment
+// becomes PARTIALLY_PAID until the total reaches the amount. Only the payer can pay, anyone but
+// the payee pays open requests, each payment is a use and the request completes once max_uses is reached.
+func PayPayment(db *pgkit.DB, ctx context.Context, paymentID uuid.UUID, userID uuid.UUID, amountCents int64) (*Payment, *Transaction, error) {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var p Payment
+	if err := scanPayment(tx.QueryRow(ctx, "SELECT "+paymentColumns+`
+		FROM payments
+		WHERE id = $1 AND deleted_at IS NULL AND `+paymentVisible+`
+		FOR UPDATE
+	`, paymentID, userID), &p); err != nil {
+		return nil, nil, err
+	}
+
+	transaction, err := payPaymentTx(tx, ctx, &p, userID, amountCents)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	committed = true
+
+	return &p, transaction, nil
+}
+
+// payPaymentTx pays the payment locked by the caller on behalf of userID and updates p.
+func payPaymentTx(tx pgx.Tx, ctx context.Context, p *Payment, userID uuid.UUID, amountCents int64) (*Transaction, error) {
+	if _, err := p.Next(userID, schemas.PaymentActionPay); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.IsOpen() {
+		if amountCents, err = p.useAmount(amountCents); err != nil {
+			return nil, err
+		}
+	} else {
+		if amountCents == 0 {
+			amountCents = p.Remaining()
+		}
+		if amountCents > p.Remaining() {
+			return nil, ErrOverpayment
+		}
+	}
+
+	transaction, err := makeTransactionTx(tx, ctx, Transaction{
+		From:        userID,
+		To:          p.To,
+		Initiator:   userID,
+		AmountCents: amountCents,
+		Description: p.Description,
+		Category:    p.Category,
+		Type:        schemas.TransactionPayment,
+		Metadata:    p.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO payment_installments (payment_id, transaction_id, payer_id, amount_cents) VALUES ($1, $2, $3, $4)",
+		p.ID, transaction.LineID, userID, amountCents); err != nil {
+		return nil, err
+	}
+
+	p.AmountPaid += amountCents
+	p.Uses++
+	switch {
+	case p.IsOpen() && p.MaxUses != nil && p.Uses >= *p.MaxUses:
+		p.Status = schemas.StatusCompleted
+	case p.IsOpen():
+		// Open requests keep accepting payments until max_uses or cancellation
+	case p.Remaining() == 0:
+		p.Status = schemas.StatusCompleted
+	default:
+		p.Status = schemas.StatusPartiallyPaid
+	}
+	if err := tx.QueryRow(ctx, "UPDATE payments SET amount_paid = $2, uses = $3, status = $4 WHERE id = $1 RETURNING updated_at", p.ID, p.AmountPaid, p.Uses, p.Status).Scan(&p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if p.SplitID != nil {
+		if err := syncSplitTx(tx, ctx, *p.SplitID); err != nil {
+			return nil, err
+		}
+	}
+
+	return transaction, nil
+}
+
+// PaymentPreview is what a payment link shows to anyone holding it.
+type PaymentPreview struct {
+	ID         uuid.UUID
+	Amount     int64
+	AmountPaid int64
+	Status     schemas.PaymentStatus
+	PayeeName  string
+	MinAmount  *int64
+	MaxAmount  *int64
+}
+
+func (p *PaymentPreview) ToPaymentPreviewFull() schemas.PaymentPreviewFull {
+	return schemas.PaymentPreviewFull{
+		ID:         p.ID.String(),
+		Amount:     p.Amount,
+		AmountPaid: p.AmountPaid,
+		Status:     p.Status,
+		PayeeName:  p.PayeeName,
+		MinAmount:  p.MinAmount,
+		MaxAmount:  p.MaxAmount,
+	}
+}
+
+func GetPaymentPreview(db *pgkit.DB, ctx context.Context, paymentID uuid.UUID) (*PaymentPreview, error) {
+	p := PaymentPreview{ID: paymentID}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT p.amount, p.amount_paid, p.status, COALESCE(NULLIF(u.name, ''), u.username, a.name, ''), p.min_amount, p.max_amount
+		FROM payments p
+		LEFT JOIN users u ON u.id = p.to_id
+		LEFT JOIN accounts a ON a.id = p.to_id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+	`, paymentID).Scan(&p.Amount, &p.AmountPaid, &p.Status, &p.PayeeName, &p.MinAmount, &p.MaxAmount)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
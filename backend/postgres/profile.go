@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type ProfileStats struct {
+	UserID                uuid.UUID
+	AmountCents           int64
+	Unlimited             bool
+	TotalTransactions     int64
+	LastActivityAt        *time.Time
+	PendingPayments       int64
+	PendingPaymentsAmount int64
+	Periods               []PeriodTotals
+}
+
+type PeriodTotals struct {
+	Name              string
+	Since             time.Time
+	IncomingCents     int64
+	OutgoingCents     int64
+	TotalTransactions int64
+}
+
+// StatsPeriod is a named window, a zero Since covers the whole history.
+type StatsPeriod struct {
+	Name  string
+	Since time.Time
+}
+
+func (s *ProfileStats) ToBalanceFull() schemas.BalanceFull {
+	full := schemas.BalanceFull{
+		Id:                    s.UserID.String(),
+		Balance:               s.AmountCents,
+		TotalTransactions:     s.TotalTransactions,
+		Unlimited:             s.Unlimited,
+		PendingPayments:       s.PendingPayments,
+		PendingPaymentsAmount: s.PendingPaymentsAmount,
+		Periods:               []schemas.PeriodTotals{},
+	}
+	if s.LastActivityAt != nil {
+		full.LastActivityAt = s.LastActivityAt.Format(time.RFC3339)
+	}
+	for _, p := range s.Periods {
+		totals := schemas.PeriodTotals{
+			Period:            p.Name,
+			Incoming:          p.IncomingCents,
+			Outgoing:          p.OutgoingCents,
+			TotalTransactions: p.TotalTransactions,
+		}
+		if !p.Since.IsZero() {
+			totals.Since = p.Since.Format(time.RFC3339)
+		}
+		full.Periods = append(full.Periods, totals)
+	}
+	return full
+}
+
+// GetProfileStats aggregates the profile in two queries, users without a balance row get zeros.
+func GetProfileStats(db *pgkit.DB, ctx context.Context, userID uuid.UUID, periods []StatsPeriod) (*ProfileStats, error) {
+	s := ProfileStats{UserID: userID}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT amount_cents FROM balances WHERE user_id = $1 AND deleted_at IS NULL), 0),
+			EXISTS (SELECT 1 FROM unlimited_balances WHERE user_id = $1 AND deleted_at IS NULL),
+			t.total, t.last_at, p.total, p.amount
+		FROM (
+			SELECT count(*) AS total, max(inserted_at) AS last_at
+			FROM transactions
+			WHERE (from_user_id = $1 OR to_user_id = $1) AND deleted_at IS NULL
+		) t, (
+			SELECT count(*) AS total, COALESCE(sum(amount - amount_paid), 0) AS amount
+			FROM payments
+			WHERE from_id = $1 AND status IN ($2, $3) AND deleted_at IS NULL
+		) p
+	`, userID, schemas.StatusPending, schemas.StatusPartiallyPaid).Scan(&s.AmountCents, &s.Unlimited, &s.TotalTransactions, &s.LastActivityAt, &s.PendingPayments, &s.PendingPaymentsAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(periods) == 0 {
+		return &s, nil
+	}
+	names := make([]string, len(periods))
+	since := make([]time.Time, len(periods))
+	for i, p := range periods {
+		names[i] = p.Name
+		since[i] = p.Since
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT p.ord,
+			COALESCE(sum(t.amount_cents) FILTER (WHERE t.to_user_id = $1), 0),
+			COALESCE(sum(t.amount_cents) FILTER (WHERE t.from_user_id = $1), 0),
+			count(t.line_id)
+		FROM unnest($2::text[], $3::timestamptz[]) WITH ORDINALITY AS p(name, since, ord)
+		LEFT JOIN transactions t
+			ON (t.from_user_id = $1 OR t.to_user_id = $1) AND t.deleted_at IS NULL AND t.inserted_at >= p.since
+		GROUP BY p.ord
+		ORDER BY p.ord
+	`, userID, names, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ord int
+		var p PeriodTotals
+		if err := rows.Scan(&ord, &p.IncomingCents, &p.OutgoingCents, &p.TotalTransactions); err != nil {
+			return nil, err
+		}
+		p.Name = names[ord-1]
+		p.Since = periods[ord-1].Since
+		s.Periods = append(s.Periods, p)
+	}
+	return &s, rows.Err()
+}
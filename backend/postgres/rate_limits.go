@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/nrf24l01/go-web-utils/pgkit"
+)
+
+// TakeRateLimitToken refills the bucket for the elapsed time and consumes one token if available.
+func TakeRateLimitToken(db *pgkit.DB, ctx context.Context, key string, capacity float64, refillPerSecond float64) (float64, bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var tokens float64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = LEAST($2, b.tokens + EXTRACT(EPOCH FROM (now() - b.updated_at)) * $3),
+			updated_at = now()
+		RETURNING tokens
+	`, key, capacity, refillPerSecond).Scan(&tokens)
+	if err != nil {
+		return 0, false, err
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		if err := tx.QueryRow(ctx, "UPDATE rate_limit_buckets SET tokens = tokens - 1 WHERE key = $1 RETURNING tokens", key).Scan(&tokens); err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, err
+	}
+	committed = true
+
+	return tokens, allowed, nil
+}
+
+// RefundRateLimitToken gives back a token consumed by TakeRateLimitToken.
+func RefundRateLimitToken(db *pgkit.DB, ctx context.Context, key string, capacity float64) error {
+	_, err := db.Pool.Exec(ctx, "UPDATE rate_limit_buckets SET tokens = LEAST($2, tokens + 1) WHERE key = $1", key, capacity)
+	return err
+}
+
+func DeleteStaleRateLimitBuckets(db *pgkit.DB, ctx context.Context, olderThan time.Duration) error {
+	_, err := db.Pool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < $1", time.Now().Add(-olderThan))
+	return err
+}
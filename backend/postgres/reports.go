@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type MoneySupply struct {
+	CirculationCents   int64
+	IssuedCents        int64
+	IssuedGrossCents   int64
+	ReturnedGrossCents int64
+	Accounts           int64
+	Issuers            int64
+}
+
+type DailyVolume struct {
+	Day          time.Time
+	VolumeCents  int64
+	Transactions int64
+}
+
+type TopAccount struct {
+	AccountID    uuid.UUID
+	Name         string
+	AmountCents  int64
+	VolumeCents  int64
+	Transactions int64
+}
+
+type ActiveUsers struct {
+	Start time.Time
+	Count int64
+}
+
+func (m *MoneySupply) ToMoneySupplyFull() schemas.MoneySupplyFull {
+	return schemas.MoneySupplyFull{
+		Circulation:   m.CirculationCents,
+		Issued:        m.IssuedCents,
+		IssuedGross:   m.IssuedGrossCents,
+		ReturnedGross: m.ReturnedGrossCents,
+		Accounts:      m.Accounts,
+		Issuers:       m.Issuers,
+	}
+}
+
+func (d *DailyVolume) ToDailyVolumeFull() schemas.DailyVolumeFull {
+	return schemas.DailyVolumeFull{
+		Day:          d.Day.Format("2006-01-02"),
+		Volume:       d.VolumeCents,
+		Transactions: d.Transactions,
+	}
+}
+
+func (a *TopAccount) ToTopAccountFull() schemas.TopAccountFull {
+	return schemas.TopAccountFull{
+		AccountID:    a.AccountID.String(),
+		Name:         a.Name,
+		Balance:      a.AmountCents,
+		Volume:       a.VolumeCents,
+		Transactions: a.Transactions,
+	}
+}
+
+func (a *ActiveUsers) ToActiveUsersFull() schemas.ActiveUsersFull {
+	return schemas.ActiveUsersFull{
+		Start:       a.Start.Format("2006-01-02"),
+		ActiveUsers: a.Count,
+	}
+}
+
+// GetMoneySupply sums balances outside the treasury, issued is what the treasury is down by.
+// Gross figures are all mints and burns ever made.
+func GetMoneySupply(db *pgkit.DB, ctx context.Context) (*MoneySupply, error) {
+	var m MoneySupply
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COALESCE(sum(b.amount_cents) FILTER (WHERE b.user_id <> $1), 0)::bigint,
+			COALESCE(-sum(b.amount_cents) FILTER (WHERE b.user_id = $1), 0)::bigint,
+			(SELECT COALESCE(sum(amount_cents), 0)::bigint FROM transactions WHERE deleted_at IS NULL AND type = $2),
+			(SELECT COALESCE(sum(amount_cents), 0)::bigint FROM transactions WHERE deleted_at IS NULL AND type = $3),
+			count(*) FILTER (WHERE b.user_id <> $1),
+			(SELECT count(*) FROM unlimited_balances WHERE deleted_at IS NULL)
+		FROM balances b
+		WHERE b.deleted_at IS NULL
+	`, TreasuryAccountID, schemas.TransactionMint, schemas.TransactionBurn).Scan(&m.CirculationCents, &m.IssuedCents, &m.IssuedGrossCents, &m.ReturnedGrossCents, &m.Accounts, &m.Issuers)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetDailyVolume returns one row per UTC day between from and to, days without transactions included.
+// Only transfers, payments and refunds count, fees and money supply operations would count the same
+// money again.
+func GetDailyVolume(db *pgkit.DB, ctx context.Context, from, to time.Time) ([]DailyVolume, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT d.day::date, COALESCE(sum(t.amount_cents), 0)::bigint, count(t.line_id)
+		FROM generate_series(($1::timestamptz AT TIME ZONE 'UTC')::date, ($2::timestamptz AT TIME ZONE 'UTC')::date, interval '1 day') AS d(day)
+		LEFT JOIN transactions t ON t.deleted_at IS NULL
+			AND t.type IN ($3, $4, $5)
+			AND t.inserted_at >= d.day AT TIME ZONE 'UTC'
+			AND t.inserted_at < (d.day + interval '1 day') AT TIME ZONE 'UTC'
+		GROUP BY d.day
+		ORDER BY d.day
+	`, from, to, schemas.TransactionTransfer, schemas.TransactionPayment, schemas.TransactionRefund)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []DailyVolume
+	for rows.Next() {
+		var d DailyVolume
+		if err := rows.Scan(&d.Day, &d.VolumeCents, &d.Transactions); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// GetTopAccounts ranks accounts by balance or by volume moved between from and to, unlimited and system accounts are skipped.
+func GetTopAccounts(db *pgkit.DB, ctx context.Context, byVolume bool, from, to time.Time, limit int) ([]TopAccount, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH volume AS (
+			SELECT l.account_id, sum(l.amount_cents)::bigint AS amount, count(*) AS total
+			FROM (
+				SELECT from_user_id AS account_id, amount_cents FROM transactions
+				WHERE deleted_at IS NULL AND inserted_at >= $1 AND inserted_at <= $2
+				UNION ALL
+				SELECT to_user_id, amount_cents FROM transactions
+				WHERE deleted_at IS NULL AND inserted_at >= $1 AND inserted_at <= $2
+			) l
+			GROUP BY l.account_id
+		)
+		SELECT a.id, COALESCE(NULLIF(u.name, ''), u.username, a.name, ''),
+			COALESCE(b.amount_cents, 0), COALESCE(v.amount, 0), COALESCE(v.total, 0)
+		FROM accounts a
+		LEFT JOIN balances b ON b.user_id = a.id AND b.deleted_at IS NULL
+		LEFT JOIN volume v ON v.account_id = a.id
+		LEFT JOIN users u ON u.id = a.id
+		WHERE a.deleted_at IS NULL AND a.kind <> 'system'
+			AND NOT EXISTS (SELECT 1 FROM unlimited_balances ub WHERE ub.user_id = a.id AND ub.deleted_at IS NULL)
+			AND (NOT $3::boolean OR v.account_id IS NOT NULL)
+		ORDER BY CASE WHEN $3::boolean THEN COALESCE(v.amount, 0) ELSE COALESCE(b.amount_cents, 0) END DESC, a.id
+		LIMIT $4
+	`, from, to, byVolume, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []TopAccount
+	for rows.Next() {
+		var a TopAccount
+		if err := rows.Scan(&a.AccountID, &a.Name, &a.AmountCents, &a.VolumeCents, &a.Transactions); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
+
+// GetActiveUsers counts distinct users who initiated at least one transaction in each bucket.
+func GetActiveUsers(db *pgkit.DB, ctx context.Context, from, to time.Time, granularity string) ([]ActiveUsers, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT b.start::date, count(DISTINCT t.initiator_id)
+		FROM generate_series(date_trunc($3, $1::timestamptz AT TIME ZONE 'UTC'), $2::timestamptz AT TIME ZONE 'UTC', ('1 ' || $3)::interval) AS b(start)
+		LEFT JOIN transactions t ON t.deleted_at IS NULL
+			AND t.inserted_at >= $1 AND t.inserted_at <= $2
+			AND t.inserted_at >= b.start AT TIME ZONE 'UTC'
+			AND t.inserted_at < (b.start + ('1 ' || $3)::interval) AT TIME ZONE 'UTC'
+		GROUP BY b.start
+		ORDER BY b.start
+	`, from, to, granularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ActiveUsers
+	for rows.Next() {
+		var a ActiveUsers
+		if err := rows.Scan(&a.Start, &a.Count); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// SplitPayment is a bill shared between several payers. Every payer gets an own child payment,
+// the split completes once it is paid in full or no child is left to pay and at least one was paid,
+// cancelling it cancels the unpaid children.
+type SplitPayment struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+
+	Creator     uuid.UUID
+	To          uuid.UUID
+	Amount      int64
+	Mode        schemas.SplitMode
+	Status      schemas.PaymentStatus
+	Description string
+	Category    string
+
+	// AmountPaid is the sum paid over all children
+	AmountPaid int64
+	Payments   []Payment
+}
+
+const splitPaymentColumns = `s.id, s.inserted_at, s.updated_at, s.creator_id, s.to_id, s.amount, s.mode, s.status, COALESCE(s.description, ''), COALESCE(s.category, ''),
+	COALESCE((SELECT sum(p.amount_paid) FROM payments p WHERE p.split_id = s.id), 0)::bigint`
+
+// splitPaymentVisible lets the creator, the payee and every participant see a split.
+const splitPaymentVisible = "(s.creator_id = $2 OR s.to_id = $2 OR EXISTS (SELECT 1 FROM payments p WHERE p.split_id = s.id AND p.from_id = $2))"
+
+func scanSplitPayment(row pgx.Row, s *SplitPayment) error {
+	return row.Scan(&s.ID, &s.InsertedAt, &s.UpdatedAt, &s.Creator, &s.To, &s.Amount, &s.Mode, &s.Status, &s.Description, &s.Category, &s.AmountPaid)
+}
+
+func (s *SplitPayment) ToSplitPaymentFull() schemas.SplitPaymentFull {
+	full := schemas.SplitPaymentFull{
+		ID:          s.ID.String(),
+		CreatedAt:   s.InsertedAt.Format(time.RFC3339),
+		Creator:     s.Creator.String(),
+		To:          s.To.String(),
+		Amount:      s.Amount,
+		AmountPaid:  s.AmountPaid,
+		Mode:        s.Mode,
+		Status:      s.Status,
+		Description: s.Description,
+		Category:    s.Category,
+		Payments:    []schemas.PaymentFull{},
+	}
+	for _, p := range s.Payments {
+		full.Payments = append(full.Payments, p.ToPaymentFull())
+	}
+	return full
+}
+
+// Roles returns what the user is to the split, participants only pay their own child payments.
+func (s *SplitPayment) Roles(userID uuid.UUID) []schemas.PaymentRole {
+	var roles []schemas.PaymentRole
+	if s.To == userID {
+		roles = append(roles, schemas.PaymentRolePayee)
+	}
+	if s.Creator == userID {
+		roles = append(roles, schemas.PaymentRoleCreator)
+	}
+	return roles
+}
+
+// SplitShares divides amount in proportion to the positive shares. The cents left over by rounding
+// down go one by one to the first participants, so the parts always add up to amount. The math is
+// done in big integers since amount * share and the sum of shares may not fit into int64.
+func SplitShares(amount int64, shares []int64) []int64 {
+	total := new(big.Int)
+	for _, s := range shares {
+		total.Add(total, big.NewInt(s))
+	}
+
+	parts := make([]int64, len(shares))
+	rest := amount
+	part := new(big.Int)
+	for i, s := range shares {
+		part.Mul(big.NewInt(amount), big.NewInt(s))
+		parts[i] = part.Quo(part, total).Int64()
+		rest -= parts[i]
+	}
+	for i := 0; rest > 0; i, rest = i+1, rest-1 {
+		parts[i%len(parts)]++
+	}
+	return parts
+}
+
+// CreateSplitPayment stores the split and a child payment of amounts[i] for every payers[i].
+func CreateSplitPayment(db *pgkit.DB, ctx context.Context, s *SplitPayment, payers []uuid.UUID, amounts []int64) error {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	s.Status = schemas.StatusPending
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO payment_splits (creator_id, to_id, amount, mode, status, description, category)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING id, inserted_at, updated_at
+	`, s.Creator, s.To, s.Amount, s.Mode, s.Status, s.Description, s.Category).Scan(&s.ID, &s.InsertedAt, &s.UpdatedAt); err != nil {
+		return err
+	}
+
+	s.Payments = nil
+	for i, payer := range payers {
+		p := Payment{
+			From:        &payer,
+			To:          s.To,
+			Creator:     s.Creator,
+			Amount:      amounts[i],
+			Description: s.Description,
+			Category:    s.Category,
+			Status:      schemas.StatusPending,
+			SplitID:     &s.ID,
+		}
+		if err := insertPaymentTx(tx, ctx, &p); err != nil {
+			return err
+		}
+		s.Payments = append(s.Payments, p)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
+
+// GetSplitPayment returns the split with all of its child payments.
+func GetSplitPayment(db *pgkit.DB, ctx context.Context, splitID uuid.UUID, userID uuid.UUID) (*SplitPayment, error) {
+	var s SplitPayment
+	if err := scanSplitPayment(db.Pool.QueryRow(ctx, "SELECT "+splitPaymentColumns+" FROM payment_splits s WHERE s.id = $1 AND s.deleted_at IS NULL AND "+splitPaymentVisible, splitID, userID), &s); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, "SELECT "+paymentColumns+" FROM payments WHERE split_id = $1 AND deleted_at IS NULL ORDER BY inserted_at, id", splitID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Payments, err = scanSplitChildren(rows); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CancelSplitPayment cancels the split and every child payment that is not finished yet,
+// only the payee or the creator can cancel.
+func CancelSplitPayment(db *pgkit.DB, ctx context.Context, splitID uuid.UUID, userID uuid.UUID) error {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Children are locked before the split, in the same order as paying or declining a child does
+	if _, err := tx.Exec(ctx, "SELECT id FROM payments WHERE split_id = $1 ORDER BY id FOR UPDATE", splitID); err != nil {
+		return err
+	}
+
+	var s SplitPayment
+	if err := scanSplitPayment(tx.QueryRow(ctx, "SELECT "+splitPaymentColumns+" FROM payment_splits s WHERE s.id = $1 AND s.deleted_at IS NULL AND "+splitPaymentVisible+" FOR UPDATE OF s", splitID, userID), &s); err != nil {
+		return err
+	}
+	status, err := NextPaymentStatus(s.Status, s.Roles(userID), schemas.PaymentActionCancel)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE payments SET status = $2 WHERE split_id = $1 AND status IN ($3, $4)",
+		s.ID, schemas.StatusCancelled, schemas.StatusPending, schemas.StatusPartiallyPaid); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE payment_splits SET status = $2 WHERE id = $1", s.ID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
+
+func scanSplitChildren(rows pgx.Rows) ([]Payment, error) {
+	defer rows.Close()
+
+	var payments []Payment
+	for rows.Next() {
+		var p Payment
+		if err := scanPayment(rows, &p); err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// syncSplitTx recounts the status of an unfinished split after one of its children changed:
+// COMPLETED once every child is paid, cancelled or declined, PARTIALLY_PAID once anything is paid.
+func syncSplitTx(tx pgx.Tx, ctx context.Context, splitID uuid.UUID) error {
+	// Lock first so the counts below see children changed by transactions that held the lock
+	if _, err := tx.Exec(ctx, "SELECT id FROM payment_splits WHERE id = $1 FOR UPDATE", splitID); err != nil {
+		return err
+	}
+	// Declined and cancelled children are finished but unpaid, a split none of them paid ends as
+	// declined when every payer declined and as cancelled otherwise
+	_, err := tx.Exec(ctx, `
+		UPDATE payment_splits s SET status = CASE
+			WHEN c.paid >= s.amount THEN $2
+			WHEN c.outstanding > 0 AND c.paid > 0 THEN $3
+			WHEN c.outstanding > 0 THEN $4
+			WHEN c.completed > 0 THEN $2
+			WHEN c.declined = c.total THEN $5
+			ELSE $6
+		END
+		FROM (
+			SELECT count(*) AS total,
+				count(*) FILTER (WHERE status IN ($4, $3)) AS outstanding,
+				count(*) FILTER (WHERE status = $2) AS completed,
+				count(*) FILTER (WHERE status = $5) AS declined,
+				COALESCE(sum(amount_paid), 0) AS paid
+			FROM payments
+			WHERE split_id = $1
+		) c
+		WHERE s.id = $1 AND s.status IN ($4, $3)
+	`, splitID, schemas.StatusCompleted, schemas.StatusPartiallyPaid, schemas.StatusPending, schemas.StatusDeclined, schemas.StatusCancelled)
+	return err
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSplitShares(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		shares []int64
+		want   []int64
+	}{
+		{"equal", 900, []int64{1, 1, 1}, []int64{300, 300, 300}},
+		{"remainder goes to the first", 1000, []int64{1, 1, 1}, []int64{334, 333, 333}},
+		{"two cents left over", 1001, []int64{1, 1, 1}, []int64{334, 334, 333}},
+		{"single participant", 1234, []int64{1}, []int64{1234}},
+		{"percentage", 1000, []int64{5000, 3000, 2000}, []int64{500, 300, 200}},
+		{"percentage rounds down", 999, []int64{3333, 3333, 3334}, []int64{333, 333, 333}},
+		{"fixed", 1500, []int64{1000, 500}, []int64{1000, 500}},
+		{"too small leaves zero parts", 1, []int64{1, 1}, []int64{1, 0}},
+		{"large amount does not overflow", math.MaxInt64, []int64{5000, 5000}, []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+		{"large fixed shares do not overflow", math.MaxInt64, []int64{math.MaxInt64 - 12, 12}, []int64{math.MaxInt64 - 12, 12}},
+		{"sum of shares beyond int64", 100, []int64{math.MaxInt64, math.MaxInt64}, []int64{50, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitShares(tt.amount, tt.shares)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+
+			var sum int64
+			for _, p := range got {
+				if p < 0 {
+					t.Fatalf("negative part in %v", got)
+				}
+				sum += p
+			}
+			if sum != tt.amount {
+				t.Fatalf("parts %v add up to %d, want %d", got, sum, tt.amount)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/nrf24l01/go-web-utils/pgkit"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
@@ -18,31 +20,92 @@ type Transaction struct {
 
 	From        uuid.UUID
 	To          uuid.UUID
+	Initiator   uuid.UUID
 	AmountCents int64
 	Description string
+	Category    string
+	Type        schemas.TransactionType
+
+	Metadata          map[string]any
+	ExternalReference string
+
+	// ParentID links a fee to the transaction it was charged for, FeeCents sums the fees of this one
+	ParentID *uuid.UUID
+	FeeCents int64
+
+	FromName string
+	ToName   string
+}
+
+// transactionSelect resolves display names of both sides from user profiles or account names.
+// $1 must be the viewing side, its own category wins over the creator's label.
+const transactionSelect = `
+	SELECT t.line_id, t.inserted_at, t.type, t.from_user_id, t.to_user_id, t.amount_cents, t.description,
+		COALESCE(tc.category, t.category, ''), t.metadata, COALESCE(t.external_reference, ''), t.parent_id,
+		COALESCE((SELECT sum(f.amount_cents) FROM transactions f WHERE f.parent_id = t.line_id AND f.type = 'fee' AND f.deleted_at IS NULL), 0)::bigint,
+		COALESCE(NULLIF(fu.name, ''), fu.username, fa.name, ''), COALESCE(NULLIF(tu.name, ''), tu.username, ta.name, '')
+	FROM transactions t
+	LEFT JOIN transaction_categories tc ON tc.transaction_id = t.line_id AND tc.user_id = $1
+	LEFT JOIN users fu ON fu.id = t.from_user_id
+	LEFT JOIN accounts fa ON fa.id = t.from_user_id
+	LEFT JOIN users tu ON tu.id = t.to_user_id
+	LEFT JOIN accounts ta ON ta.id = t.to_user_id`
+
+func scanTransaction(row pgx.Row, t *Transaction) error {
+	return row.Scan(&t.LineID, &t.InsertedAt, &t.Type, &t.From, &t.To, &t.AmountCents, &t.Description, &t.Category, &t.Metadata, &t.ExternalReference, &t.ParentID, &t.FeeCents, &t.FromName, &t.ToName)
 }
 
 func (t *Transaction) Insert(db *pgkit.DB, ctx context.Context) error {
-	if err := db.Pool.QueryRow(ctx, "INSERT INTO transactions (from_user_id, to_user_id, amount_cents, description) VALUES ($1, $2, $3, $4) RETURNING line_id, inserted_at, updated_at",
-		t.From, t.To, t.AmountCents, t.Description).Scan(&t.LineID, &t.InsertedAt, &t.UpdatedAt); err != nil {
-		return err
+	if t.Initiator == uuid.Nil {
+		t.Initiator = t.From
+	}
+	if t.Type == "" {
+		t.Type = schemas.TransactionTransfer
+	}
+	if err := db.Pool.QueryRow(ctx, insertTransactionQuery, t.insertArgs()...).Scan(&t.LineID, &t.InsertedAt, &t.UpdatedAt); err != nil {
+		return transactionInsertError(err)
 	}
 	return nil
 }
 
 func (t *Transaction) ToTransactionFull() schemas.TransactionFull {
-	return schemas.TransactionFull{
-		ID:        t.LineID.String(),
-		CreatedAt: t.InsertedAt.Format(time.RFC3339),
-		Source:    t.From.String(),
-		Target:    t.To.String(),
-		Amount:    t.AmountCents,
-		Comment:   t.Description,
+	full := schemas.TransactionFull{
+		ID:         t.LineID.String(),
+		CreatedAt:  t.InsertedAt.Format(time.RFC3339),
+		Type:       t.Type,
+		Source:     t.From.String(),
+		SourceName: t.FromName,
+		Target:     t.To.String(),
+		TargetName: t.ToName,
+		Amount:     t.AmountCents,
+		Comment:    t.Description,
+		Category:   t.Category,
+		Fee:        t.FeeCents,
+
+		Metadata:          t.Metadata,
+		ExternalReference: t.ExternalReference,
+	}
+	if t.ParentID != nil {
+		full.ParentID = t.ParentID.String()
 	}
+	return full
+}
+
+// TransactionFilter narrows transaction lists, zero values match everything.
+// Metadata is a JSON object the transaction metadata must contain.
+type TransactionFilter struct {
+	Type              schemas.TransactionType
+	Metadata          []byte
+	ExternalReference string
 }
 
-func GetTransactionsByUserID(db *pgkit.DB, ctx context.Context, userID uuid.UUID, limit, offset int) ([]Transaction, error) {
-	rows, err := db.Pool.Query(ctx, "SELECT line_id, inserted_at, from_user_id, to_user_id, amount_cents, description FROM transactions WHERE (from_user_id = $1 OR to_user_id = $1) AND (deleted_at IS NULL) ORDER BY inserted_at DESC LIMIT $2 OFFSET $3", userID, limit, offset)
+func GetTransactionsByUserID(db *pgkit.DB, ctx context.Context, userID uuid.UUID, filter TransactionFilter, limit, offset int) ([]Transaction, error) {
+	rows, err := db.Pool.Query(ctx, transactionSelect+`
+		WHERE (t.from_user_id = $1 OR t.to_user_id = $1) AND (t.deleted_at IS NULL)
+			AND ($4 = '' OR t.type = $4)
+			AND ($5::jsonb IS NULL OR t.metadata @> $5::jsonb)
+			AND ($6 = '' OR t.external_reference = $6)
+		ORDER BY t.inserted_at DESC LIMIT $2 OFFSET $3`, userID, limit, offset, filter.Type, filter.Metadata, filter.ExternalReference)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +114,7 @@ func GetTransactionsByUserID(db *pgkit.DB, ctx context.Context, userID uuid.UUID
 	var transactions []Transaction
 	for rows.Next() {
 		var t Transaction
-		if err := rows.Scan(&t.LineID, &t.InsertedAt, &t.From, &t.To, &t.AmountCents, &t.Description); err != nil {
+		if err := scanTransaction(rows, &t); err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, t)
@@ -62,14 +125,15 @@ func GetTransactionsByUserID(db *pgkit.DB, ctx context.Context, userID uuid.UUID
 
 func GetTransactionByID(db *pgkit.DB, ctx context.Context, transactionID uuid.UUID, userID uuid.UUID) (*Transaction, error) {
 	var t Transaction
-	if err := db.Pool.QueryRow(ctx, "SELECT line_id, inserted_at, from_user_id, to_user_id, amount_cents, description FROM transactions WHERE line_id = $1 AND deleted_at IS NULL AND (from_user_id = $2 OR to_user_id = $2)", transactionID, userID).
-		Scan(&t.LineID, &t.InsertedAt, &t.From, &t.To, &t.AmountCents, &t.Description); err != nil {
+	if err := scanTransaction(db.Pool.QueryRow(ctx, transactionSelect+" WHERE t.line_id = $2 AND t.deleted_at IS NULL AND (t.from_user_id = $1 OR t.to_user_id = $1)", userID, transactionID), &t); err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func MakeTransaction(db *pgkit.DB, ctx context.Context, from uuid.UUID, to uuid.UUID, amount int64, description string) (*Transaction, error) {
+// MakeTransaction moves draft.AmountCents from draft.From to draft.To, Initiator defaults to From.
+// A matching fee rule charges a linked fee transaction in the same database transaction.
+func MakeTransaction(db *pgkit.DB, ctx context.Context, draft Transaction) (*Transaction, error) {
 	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
 	if err != nil {
 		return nil, err
@@ -81,6 +145,26 @@ func MakeTransaction(db *pgkit.DB, ctx context.Context, from uuid.UUID, to uuid.
 		}
 	}()
 
+	transaction, err := makeTransactionTx(tx, ctx, draft)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return transaction, nil
+}
+
+func makeTransactionTx(tx pgx.Tx, ctx context.Context, draft Transaction) (*Transaction, error) {
+	from, to, amount := draft.From, draft.To, draft.AmountCents
+
+	if err := checkFreezesTx(tx, ctx, from, to); err != nil {
+		return nil, err
+	}
+
 	balances, err := getBalancesForUpdate(tx, ctx, from, to)
 	if err != nil {
 		return nil, err
@@ -91,31 +175,51 @@ func MakeTransaction(db *pgkit.DB, ctx context.Context, from uuid.UUID, to uuid.
 		return nil, err
 	}
 
-	if !isUnlimited {
-		fromBalance := balances[from]
-		if fromBalance < amount {
-			return nil, ErrCantPay
+	transaction := draft
+	if transaction.Initiator == uuid.Nil {
+		transaction.Initiator = from
+	}
+	if transaction.Type == "" {
+		transaction.Type = schemas.TransactionTransfer
+	}
+
+	quote := &FeeQuote{AmountCents: amount}
+	if from != to {
+		if quote, err = quoteFeeTx(tx, ctx, transaction.Type, to, amount); err != nil {
+			return nil, err
 		}
 	}
+	debit := amount
+	if quote.Payer() == schemas.FeePayerSource {
+		debit += quote.FeeCents
+	}
 
-	transaction := Transaction{
-		From:        from,
-		To:          to,
-		AmountCents: amount,
-		Description: description,
+	// The treasury is the only account allowed to go negative, unlimited accounts get the shortfall minted
+	// except for burns, which can only take money the account really has
+	if fromBalance := balances[from]; from != TreasuryAccountID && fromBalance < debit {
+		if !isUnlimited || transaction.Type == schemas.TransactionBurn {
+			return nil, ErrCantPay
+		}
+		if err := mintShortfallTx(tx, ctx, from, transaction.Initiator, debit-max(fromBalance, 0)); err != nil {
+			return nil, err
+		}
 	}
 
+	if err := ensureAccountsTx(tx, ctx, from, to); err != nil {
+		return nil, err
+	}
 	if err := insertTransactionTx(tx, ctx, &transaction); err != nil {
 		return nil, err
 	}
 	if err := updateBalancesTx(tx, ctx, from, to, amount); err != nil {
 		return nil, err
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+	if quote.FeeCents > 0 {
+		if err := chargeFeeTx(tx, ctx, &transaction, quote); err != nil {
+			return nil, err
+		}
+		transaction.FeeCents = quote.FeeCents
 	}
-	committed = true
 
 	return &transaction, nil
 }
@@ -165,6 +269,33 @@ func updateBalancesTx(tx pgx.Tx, ctx context.Context, from uuid.UUID, to uuid.UU
 }
 
 func insertTransactionTx(tx pgx.Tx, ctx context.Context, t *Transaction) error {
-	return tx.QueryRow(ctx, "INSERT INTO transactions (from_user_id, to_user_id, amount_cents, description) VALUES ($1, $2, $3, $4) RETURNING line_id, inserted_at, updated_at",
-		t.From, t.To, t.AmountCents, t.Description).Scan(&t.LineID, &t.InsertedAt, &t.UpdatedAt)
+	if t.Type == "" {
+		t.Type = schemas.TransactionTransfer
+	}
+	if err := tx.QueryRow(ctx, insertTransactionQuery, t.insertArgs()...).Scan(&t.LineID, &t.InsertedAt, &t.UpdatedAt); err != nil {
+		return transactionInsertError(err)
+	}
+	return nil
+}
+
+const insertTransactionQuery = `
+	INSERT INTO transactions (from_user_id, to_user_id, initiator_id, amount_cents, description, category, type, metadata, external_reference, parent_id)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NULLIF($9, ''), $10)
+	RETURNING line_id, inserted_at, updated_at`
+
+func (t *Transaction) insertArgs() []any {
+	metadata := t.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	return []any{t.From, t.To, t.Initiator, t.AmountCents, t.Description, t.Category, t.Type, metadata, t.ExternalReference, t.ParentID}
+}
+
+// transactionInsertError maps a unique violation (23505) on the external reference to ErrDuplicateReference.
+func transactionInsertError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "transactions_external_reference_idx" {
+		return ErrDuplicateReference
+	}
+	return err
 }
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+// TreasuryAccountID is the system account money is minted from and burned into,
+// its negative balance is the money supply.
+var TreasuryAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+const autoMintDescription = "Issued for unlimited account"
+
+type Treasury struct {
+	AmountCents int64
+	MintedCents int64
+	BurnedCents int64
+}
+
+func (t *Treasury) ToTreasuryFull() schemas.TreasuryFull {
+	return schemas.TreasuryFull{
+		AccountID: TreasuryAccountID.String(),
+		Supply:    -t.AmountCents,
+		Minted:    t.MintedCents,
+		Burned:    t.BurnedCents,
+	}
+}
+
+// Mint issues new money from the treasury to an account.
+func Mint(db *pgkit.DB, ctx context.Context, to, initiator uuid.UUID, amountCents int64, description string) (*Transaction, error) {
+	return MakeTransaction(db, ctx, Transaction{
+		From:        TreasuryAccountID,
+		To:          to,
+		Initiator:   initiator,
+		AmountCents: amountCents,
+		Description: description,
+		Type:        schemas.TransactionMint,
+	})
+}
+
+// Burn takes money out of circulation, the account must have enough funds. Unlimited accounts
+// included, minting their shortfall only to burn it would inflate both totals.
+func Burn(db *pgkit.DB, ctx context.Context, from, initiator uuid.UUID, amountCents int64, description string) (*Transaction, error) {
+	return MakeTransaction(db, ctx, Transaction{
+		From:        from,
+		To:          TreasuryAccountID,
+		Initiator:   initiator,
+		AmountCents: amountCents,
+		Description: description,
+		Type:        schemas.TransactionBurn,
+	})
+}
+
+func GetTreasury(db *pgkit.DB, ctx context.Context) (*Treasury, error) {
+	var t Treasury
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT amount_cents FROM balances WHERE user_id = $1 AND deleted_at IS NULL), 0),
+			COALESCE(sum(amount_cents) FILTER (WHERE type = $2), 0)::bigint,
+			COALESCE(sum(amount_cents) FILTER (WHERE type = $3), 0)::bigint
+		FROM transactions
+		WHERE deleted_at IS NULL AND (from_user_id = $1 OR to_user_id = $1)
+	`, TreasuryAccountID, schemas.TransactionMint, schemas.TransactionBurn).Scan(&t.AmountCents, &t.MintedCents, &t.BurnedCents)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// mintShortfallTx records the money an unlimited account spends beyond its balance as a mint.
+func mintShortfallTx(tx pgx.Tx, ctx context.Context, to, initiator uuid.UUID, amountCents int64) error {
+	mint := Transaction{
+		From:        TreasuryAccountID,
+		To:          to,
+		Initiator:   initiator,
+		AmountCents: amountCents,
+		Description: autoMintDescription,
+		Type:        schemas.TransactionMint,
+	}
+	if err := insertTransactionTx(tx, ctx, &mint); err != nil {
+		return err
+	}
+	return updateBalancesTx(tx, ctx, TreasuryAccountID, to, amountCents)
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+type User struct {
+	ID         uuid.UUID
+	InsertedAt time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+
+	Username     string
+	Name         string
+	Email        string
+	Discoverable bool
+	LastSeenAt   time.Time
+}
+
+const userColumns = "id, inserted_at, updated_at, deleted_at, COALESCE(username, ''), COALESCE(name, ''), COALESCE(email, ''), discoverable, last_seen_at"
+
+func scanUser(row pgx.Row, u *User) error {
+	return row.Scan(&u.ID, &u.InsertedAt, &u.UpdatedAt, &u.DeletedAt, &u.Username, &u.Name, &u.Email, &u.Discoverable, &u.LastSeenAt)
+}
+
+func (u *User) ToUserPublic() schemas.UserPublic {
+	return schemas.UserPublic{
+		ID:       u.ID.String(),
+		Username: u.Username,
+		Name:     u.Name,
+	}
+}
+
+func (u *User) ToUserFull() schemas.UserFull {
+	return schemas.UserFull{
+		ID:           u.ID.String(),
+		Username:     u.Username,
+		Name:         u.Name,
+		Email:        u.Email,
+		Discoverable: u.Discoverable,
+	}
+}
+
+// UpsertUserProfile stores the claims of an authenticated user. The row is only rewritten
+// when the profile changed or last_seen_at is stale, so most requests don't write.
+func UpsertUserProfile(db *pgkit.DB, ctx context.Context, id uuid.UUID, username, name, email string) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO users (id, username, name, email)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (id) DO UPDATE
+		SET username = EXCLUDED.username, name = EXCLUDED.name, email = EXCLUDED.email, last_seen_at = now()
+		WHERE users.username IS DISTINCT FROM EXCLUDED.username
+			OR users.name IS DISTINCT FROM EXCLUDED.name
+			OR users.email IS DISTINCT FROM EXCLUDED.email
+			OR users.last_seen_at < now() - interval '5 minutes'
+	`, id, username, name, email)
+	return err
+}
+
+func GetUserByID(db *pgkit.DB, ctx context.Context, id uuid.UUID) (*User, error) {
+	var u User
+	if err := scanUser(db.Pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1 AND deleted_at IS NULL", id), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUserByUsername matches case-insensitively, usernames from different issuers may collide.
+func GetUserByUsername(db *pgkit.DB, ctx context.Context, username string) (*User, error) {
+	rows, err := db.Pool.Query(ctx, "SELECT "+userColumns+" FROM users WHERE lower(username) = lower($1) AND deleted_at IS NULL LIMIT 2", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := scanUser(rows, &u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, pgx.ErrNoRows
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, ErrAmbiguousUsername
+	}
+}
+
+// SearchUsers finds discoverable users by username prefix or part of the name, byEmail also
+// matches the exact email and is meant for admins only, anyone else could probe addresses with it.
+func SearchUsers(db *pgkit.DB, ctx context.Context, query string, byEmail bool, limit int) ([]User, error) {
+	pattern := escapeLike(strings.ToLower(query))
+	rows, err := db.Pool.Query(ctx, "SELECT "+userColumns+` FROM users
+		WHERE deleted_at IS NULL AND discoverable
+			AND (lower(username) LIKE $1 || '%' OR lower(name) LIKE '%' || $1 || '%' OR ($4 AND lower(email) = lower($2)))
+		ORDER BY lower(username) = lower($2) DESC, username
+		LIMIT $3`, pattern, query, limit, byEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := scanUser(rows, &u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func SetUserDiscoverable(db *pgkit.DB, ctx context.Context, id uuid.UUID, discoverable bool) (*User, error) {
+	var u User
+	if err := scanUser(db.Pool.QueryRow(ctx, `
+		INSERT INTO users (id, discoverable) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET discoverable = EXCLUDED.discoverable
+		RETURNING `+userColumns, id, discoverable), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
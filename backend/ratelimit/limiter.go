@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+type Class string
+
+const (
+	ClassRead          Class = "read"
+	ClassTransfer      Class = "transfer"
+	ClassPaymentCreate Class = "payment_create"
+)
+
+type Budget struct {
+	Burst           int64
+	RefillPerSecond float64
+}
+
+// Window is the time an empty bucket needs to refill completely.
+func (b Budget) Window() time.Duration {
+	if b.RefillPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(b.Burst) / b.RefillPerSecond * float64(time.Second))
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration
+	RetryAfter time.Duration
+	Window     time.Duration
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, budget Budget) (float64, bool, error)
+	// Refund returns a token taken by Take, the bucket never grows past its burst.
+	Refund(ctx context.Context, key string, budget Budget) error
+}
+
+type Limiter struct {
+	store            Store
+	budgets          map[Class]Budget
+	clientMultiplier float64
+}
+
+func New(store Store, cfg *config.RateLimitConfig) *Limiter {
+	perSecond := func(perMinute float64) float64 { return perMinute / 60 }
+	return &Limiter{
+		store: store,
+		budgets: map[Class]Budget{
+			ClassRead:          {Burst: cfg.ReadBurst, RefillPerSecond: perSecond(cfg.ReadPerMinute)},
+			ClassTransfer:      {Burst: cfg.TransferBurst, RefillPerSecond: perSecond(cfg.TransferPerMinute)},
+			ClassPaymentCreate: {Burst: cfg.PaymentBurst, RefillPerSecond: perSecond(cfg.PaymentPerMinute)},
+		},
+		clientMultiplier: cfg.ClientMultiplier,
+	}
+}
+
+func NewFromConfig(cfg *config.RateLimitConfig, db *pgkit.DB) (*Limiter, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return New(NewMemoryStore(), cfg), nil
+	case "postgres":
+		return New(NewPostgresStore(db), cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+}
+
+// Allow consumes a token from both the user and the client bucket of the class, a request
+// denied by the client bucket gives the user token back. The returned result describes the
+// most restrictive of the two.
+func (l *Limiter) Allow(ctx context.Context, class Class, userID string, clientID string) (Result, error) {
+	budget, ok := l.budgets[class]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown rate limit class %q", class)
+	}
+
+	userKey := "user:" + string(class) + ":" + userID
+	res, err := l.take(ctx, userKey, budget)
+	if err != nil || !res.Allowed || clientID == "" {
+		return res, err
+	}
+
+	clientBudget := Budget{
+		Burst:           int64(math.Ceil(float64(budget.Burst) * l.clientMultiplier)),
+		RefillPerSecond: budget.RefillPerSecond * l.clientMultiplier,
+	}
+	clientRes, err := l.take(ctx, "client:"+string(class)+":"+clientID, clientBudget)
+	if err != nil {
+		return res, err
+	}
+	if !clientRes.Allowed {
+		return clientRes, l.store.Refund(ctx, userKey, budget)
+	}
+	if float64(clientRes.Remaining)/float64(clientRes.Limit) < float64(res.Remaining)/float64(res.Limit) {
+		return clientRes, nil
+	}
+	return res, nil
+}
+
+// AllowIP consumes a token from the bucket of the client address, it guards public routes
+// that have no user to key on.
+func (l *Limiter) AllowIP(ctx context.Context, class Class, ip string) (Result, error) {
+	budget, ok := l.budgets[class]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown rate limit class %q", class)
+	}
+	return l.take(ctx, "ip:"+string(class)+":"+ip, budget)
+}
+
+func (l *Limiter) take(ctx context.Context, key string, budget Budget) (Result, error) {
+	tokens, allowed, err := l.store.Take(ctx, key, budget)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     budget.Burst,
+		Remaining: int64(math.Floor(math.Max(tokens, 0))),
+		Window:    budget.Window(),
+	}
+	if budget.RefillPerSecond > 0 {
+		res.Reset = secondsToDuration((float64(budget.Burst) - tokens) / budget.RefillPerSecond)
+		if !allowed {
+			res.RetryAfter = secondsToDuration((1 - tokens) / budget.RefillPerSecond)
+		}
+	}
+	return res, nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s)) * time.Second
+}
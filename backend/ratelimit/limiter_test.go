@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/config"
+)
+
+// staticBudget never refills, so every test sees exactly the tokens it took.
+var staticBudget = Budget{Burst: 2}
+
+func newTestLimiter(store Store) *Limiter {
+	return New(store, &config.RateLimitConfig{ReadBurst: 2, ClientMultiplier: 0.5})
+}
+
+func testStoreTakeAndRefund(t *testing.T, store Store, key string) {
+	t.Helper()
+	ctx := context.Background()
+
+	for i, want := range []bool{true, true, false} {
+		_, allowed, err := store.Take(ctx, key, staticBudget)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+		if allowed != want {
+			t.Fatalf("take %d: allowed = %v, want %v", i, allowed, want)
+		}
+	}
+
+	if err := store.Refund(ctx, key, staticBudget); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if _, allowed, err := store.Take(ctx, key, staticBudget); err != nil || !allowed {
+		t.Fatalf("take after refund: allowed = %v, err = %v", allowed, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreTakeAndRefund(t, NewMemoryStore(), "user:read:a")
+}
+
+func TestMemoryStoreRefundKeepsBurst(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, _, err := store.Take(ctx, "k", staticBudget); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := store.Refund(ctx, "k", staticBudget); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tokens, _, err := store.Take(ctx, "k", staticBudget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != float64(staticBudget.Burst-1) {
+		t.Fatalf("tokens = %v, want %v", tokens, staticBudget.Burst-1)
+	}
+}
+
+// TestPostgresStore needs a migrated database in TEST_DATABASE_URL.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	key := "test:" + uuid.NewString()
+	defer func() {
+		_, _ = pool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE key = $1", key)
+	}()
+	testStoreTakeAndRefund(t, NewPostgresStore(&pgkit.DB{Pool: pool}), key)
+}
+
+func TestLimiterClientDenialKeepsUserToken(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLimiter(NewMemoryStore())
+
+	// The client bucket holds a single token, the user bucket two
+	steps := []struct {
+		client  string
+		allowed bool
+	}{
+		{"shop", true},
+		{"shop", false},
+		{"cafe", true},
+		{"club", false},
+	}
+	for i, s := range steps {
+		res, err := l.Allow(ctx, ClassRead, "user", s.client)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if res.Allowed != s.allowed {
+			t.Fatalf("step %d (%s): allowed = %v, want %v", i, s.client, res.Allowed, s.allowed)
+		}
+	}
+}
+
+func TestLimiterWithoutClient(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLimiter(NewMemoryStore())
+
+	for i, want := range []bool{true, true, false} {
+		res, err := l.Allow(ctx, ClassRead, "user", "")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("request %d: allowed = %v, want %v", i, res.Allowed, want)
+		}
+		if res.Limit != 2 {
+			t.Fatalf("request %d: limit = %d, want 2", i, res.Limit)
+		}
+	}
+}
+
+func TestLimiterIPBucketsAreSeparate(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLimiter(NewMemoryStore())
+
+	steps := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.1", true},
+		{"10.0.0.1", false},
+		{"10.0.0.2", true},
+	}
+	for i, s := range steps {
+		res, err := l.AllowIP(ctx, ClassRead, s.ip)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if res.Allowed != s.allowed {
+			t.Fatalf("step %d (%s): allowed = %v, want %v", i, s.ip, res.Allowed, s.allowed)
+		}
+	}
+
+	// An address never shares the bucket of a user with the same id
+	if res, err := l.Allow(ctx, ClassRead, "10.0.0.1", ""); err != nil || !res.Allowed {
+		t.Fatalf("user bucket: allowed = %v, err = %v", res.Allowed, err)
+	}
+}
+
+func TestLimiterUnknownClass(t *testing.T) {
+	l := newTestLimiter(NewMemoryStore())
+	if _, err := l.Allow(context.Background(), Class("unknown"), "user", ""); err == nil {
+		t.Fatal("expected an error for an unknown class")
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	budget    Budget
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, budget Budget) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(budget.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.budget = budget
+	b.tokens = math.Min(float64(budget.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*budget.RefillPerSecond)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+func (s *MemoryStore) Refund(_ context.Context, key string, budget Budget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[key]; ok {
+		b.tokens = math.Min(float64(budget.Burst), b.tokens+1)
+	}
+	return nil
+}
+
+// sweep drops buckets that have refilled completely, they behave the same as missing ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) >= b.budget.Window() {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nrf24l01/go-web-utils/pgkit"
+	"github.com/silaeder-labs/bank/backend/postgres"
+)
+
+const (
+	postgresSweepInterval = 10 * time.Minute
+	postgresStaleAfter    = 24 * time.Hour
+)
+
+// PostgresStore keeps buckets in the database so limits hold across replicas.
+type PostgresStore struct {
+	db        *pgkit.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *pgkit.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, budget Budget) (float64, bool, error) {
+	s.sweep(ctx)
+	return postgres.TakeRateLimitToken(s.db, ctx, key, float64(budget.Burst), budget.RefillPerSecond)
+}
+
+func (s *PostgresStore) Refund(ctx context.Context, key string, budget Budget) error {
+	return postgres.RefundRateLimitToken(s.db, ctx, key, float64(budget.Burst))
+}
+
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < postgresSweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	_ = postgres.DeleteStaleRateLimitBuckets(s.db, ctx, postgresStaleAfter)
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func RegisterAccountRoutes(e *echo.Group, h *handlers.Handler) {
+	g := e.Group("/accounts")
+	g.Use(middleware.JWTMiddleware(h, auth.Authenticated()))
+	g.Use(middleware.RateLimitMiddleware(h, ratelimit.ClassRead))
+
+	g.POST("", h.CreateAccountHandler, echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateAccountRequest{}
+	}))
+	g.GET("", h.ListAccountsHandler)
+	g.GET("/:uuid", h.GetAccountHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/:uuid/members", h.ListAccountMembersHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	g.PUT("/:uuid/members", h.SetAccountMemberHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.SetAccountMemberRequest{}
+	}))
+	g.DELETE("/:uuid/members/:user", h.RemoveAccountMemberHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.PathUuidV4Middleware("user"))
+	g.GET("/:uuid/transactions", h.GetAccountTransactionsHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.GetTransactionsRequest{}
+	}))
+
+	g.GET("/:uuid/approval-policy", h.GetApprovalPolicyHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	g.PUT("/:uuid/approval-policy", h.SetApprovalPolicyHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.SetApprovalPolicyRequest{}
+	}))
+	g.DELETE("/:uuid/approval-policy", h.DeleteApprovalPolicyHandler, echokitMw.PathUuidV4Middleware("uuid"))
+
+	pending := g.Group("/:uuid/pending-transfers", echokitMw.PathUuidV4Middleware("uuid"))
+	pending.GET("", h.ListPendingTransfersHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ListPendingTransfersRequest{}
+	}))
+	pending.GET("/:transfer", h.GetPendingTransferHandler, echokitMw.PathUuidV4Middleware("transfer"))
+	pending.POST("/:transfer/approve", h.ApprovePendingTransferHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassTransfer), echokitMw.PathUuidV4Middleware("transfer"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.TransferDecisionRequest{}
+	}))
+	pending.POST("/:transfer/reject", h.RejectPendingTransferHandler, echokitMw.PathUuidV4Middleware("transfer"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.TransferDecisionRequest{}
+	}))
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func RegisterAdminRoutes(e *echo.Group, h *handlers.Handler) {
+	g := e.Group("/admin")
+	g.Use(middleware.JWTMiddleware(h, auth.Admin()))
+	g.Use(middleware.RateLimitMiddleware(h, ratelimit.ClassRead))
+
+	keys := g.Group("/api-keys")
+	keys.POST("", h.CreateApiKeyHandler, echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateApiKeyRequest{}
+	}))
+	keys.GET("", h.ListApiKeysHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ListApiKeysRequest{}
+	}))
+	keys.GET("/:uuid", h.GetApiKeyHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	keys.DELETE("/:uuid", h.RevokeApiKeyHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	keys.POST("/:uuid/rotate", h.RotateApiKeyHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.RotateApiKeyRequest{}
+	}))
+
+	accounts := g.Group("/accounts")
+	accounts.GET("/:uuid/freezes", h.ListAccountFreezesHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	accounts.POST("/:uuid/freeze", h.FreezeAccountHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.FreezeAccountRequest{}
+	}))
+	accounts.DELETE("/:uuid/freeze", h.UnfreezeAccountHandler, echokitMw.PathUuidV4Middleware("uuid"))
+
+	fees := g.Group("/fee-rules")
+	fees.GET("", h.ListFeeRulesHandler)
+	fees.POST("", h.CreateFeeRuleHandler, echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateFeeRuleRequest{}
+	}))
+	fees.DELETE("/:uuid", h.DeleteFeeRuleHandler, echokitMw.PathUuidV4Middleware("uuid"))
+
+	reports := g.Group("/reports")
+	reports.GET("/money-supply", h.MoneySupplyReportHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.MoneySupplyRequest{}
+	}))
+	reports.GET("/daily-volume", h.DailyVolumeReportHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ReportRangeRequest{}
+	}))
+	reports.GET("/top-accounts", h.TopAccountsReportHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.TopAccountsRequest{}
+	}))
+	reports.GET("/active-users", h.ActiveUsersReportHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ActiveUsersRequest{}
+	}))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func RegisterMandateRoutes(e *echo.Group, h *handlers.Handler) {
+	g := e.Group("/mandates")
+	g.GET("", h.ListMandatesHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ListMandatesRequest{}
+	}))
+	g.POST("", h.CreateMandateHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateMandateRequest{}
+	}))
+	g.DELETE("/:uuid", h.RevokeMandateHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/:uuid/debits", h.ListMandateDebitsHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+}
@@ -3,17 +3,39 @@ package routes
 import (
 	"github.com/labstack/echo/v4"
 	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/handlers"
 	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
 
 func RegisterPaymentsRoutes(e *echo.Group, h *handlers.Handler) {
 	g := e.Group("/payments")
-	g.POST("", h.CreatePaymentHandler, middleware.JWTMiddleware(h, true), echokitMw.BodyValidationMiddleware(func() interface{} {
+	g.POST("", h.CreatePaymentHandler, middleware.JWTMiddleware(h, auth.Scope(auth.ScopePaymentCreate)), middleware.RateLimitMiddleware(h, ratelimit.ClassPaymentCreate), echokitMw.BodyValidationMiddleware(func() interface{} {
 		return &schemas.CreatePaymentRequest{}
 	}))
-	g.GET("/:uuid", h.GetPaymentHandler, middleware.JWTMiddleware(h, false), echokitMw.PathUuidV4Middleware("uuid"))
-	g.DELETE("/:uuid", h.RemovePaymentHandler, middleware.JWTMiddleware(h, false), echokitMw.PathUuidV4Middleware("uuid"))
-	g.POST("/:uuid/pay", h.PayPaymentHandler, middleware.JWTMiddleware(h, false), echokitMw.PathUuidV4Middleware("uuid"))
+	g.POST("/requests", h.CreateMoneyRequestHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassPaymentCreate), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateMoneyRequestRequest{}
+	}))
+	g.POST("/split", h.CreateSplitPaymentHandler, middleware.JWTMiddleware(h, auth.Scope(auth.ScopePaymentCreate)), middleware.RateLimitMiddleware(h, ratelimit.ClassPaymentCreate), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.CreateSplitPaymentRequest{}
+	}))
+	g.GET("/split/:uuid", h.GetSplitPaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.DELETE("/split/:uuid", h.CancelSplitPaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/:uuid", h.GetPaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.DELETE("/:uuid", h.RemovePaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/:uuid/link", h.GetPaymentLinkHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/:uuid/qr", h.GetPaymentQRHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"), echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.PaymentQRRequest{}
+	}))
+	g.GET("/:uuid/preview", h.GetPaymentPreviewHandler, middleware.IPRateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"), echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.PaymentPreviewRequest{}
+	}))
+	g.POST("/:uuid/decline", h.DeclinePaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.DeclinePaymentRequest{}
+	}))
+	g.POST("/:uuid/pay", h.PayPaymentHandler, middleware.JWTMiddleware(h, auth.Authenticated()), middleware.RateLimitMiddleware(h, ratelimit.ClassTransfer), echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.PayPaymentRequest{}
+	}))
 }
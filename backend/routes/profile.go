@@ -2,12 +2,37 @@ package routes
 
 import (
 	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/handlers"
 	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
 )
 
 func RegisterProfileRoutes(e *echo.Group, h *handlers.Handler) {
 	g := e.Group("/profile")
-	g.Use(middleware.JWTMiddleware(h, false))
-	g.GET("/me", h.GetBalanceHandler)
+	g.Use(middleware.JWTMiddleware(h, auth.Authenticated()))
+	g.Use(middleware.RateLimitMiddleware(h, ratelimit.ClassRead))
+	g.GET("/me", h.GetBalanceHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.GetProfileRequest{}
+	}))
+	g.GET("/balance", h.GetBalanceAtHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.GetBalanceAtRequest{}
+	}))
+	g.GET("/balance-history", h.GetBalanceHistoryHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.GetBalanceHistoryRequest{}
+	}))
+	g.GET("/analytics", h.GetAnalyticsHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.GetAnalyticsRequest{}
+	}))
+	g.GET("/notifications", h.ListNotificationsHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.ListNotificationsRequest{}
+	}))
+	g.POST("/notifications/read", h.MarkNotificationsReadHandler)
+	g.GET("/blocked", h.ListMoneyRequestBlocksHandler)
+	g.PUT("/blocked/:uuid", h.SetMoneyRequestBlockHandler, echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.SetMoneyRequestBlockRequest{}
+	}))
+	g.DELETE("/blocked/:uuid", h.DeleteMoneyRequestBlockHandler, echokitMw.PathUuidV4Middleware("uuid"))
 }
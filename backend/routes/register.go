@@ -9,4 +9,9 @@ func RegisterRoutes(e *echo.Group, h *handlers.Handler) {
 	RegisterTransactionRoutes(e, h)
 	RegisterProfileRoutes(e, h)
 	RegisterPaymentsRoutes(e, h)
+	RegisterAccountRoutes(e, h)
+	RegisterUserRoutes(e, h)
+	RegisterAdminRoutes(e, h)
+	RegisterTreasuryRoutes(e, h)
+	RegisterMandateRoutes(e, h)
 }
@@ -3,19 +3,28 @@ package routes
 import (
 	"github.com/labstack/echo/v4"
 	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
 	"github.com/silaeder-labs/bank/backend/handlers"
 	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
 	"github.com/silaeder-labs/bank/backend/schemas"
 )
 
 func RegisterTransactionRoutes(e *echo.Group, h *handlers.Handler) {
 	g := e.Group("/transactions")
-	g.Use(middleware.JWTMiddleware(h, false))
-	g.POST("", h.CreateTransactionHandler, echokitMw.BodyValidationMiddleware(func() interface{} {
+	g.Use(middleware.JWTMiddleware(h, auth.Authenticated()))
+	g.POST("", h.CreateTransactionHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassTransfer), echokitMw.BodyValidationMiddleware(func() interface{} {
 		return &schemas.CreateTransactionRequest{}
 	}))
-	g.GET("", h.GetTransactionsHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+	g.GET("", h.GetTransactionsHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.QueryValidationMiddleware(func() interface{} {
 		return &schemas.GetTransactionsRequest{}
 	}))
-	g.GET("/:uuid", h.GetTransactionByIDHandler, echokitMw.PathUuidV4Middleware("uuid"))
+	g.GET("/quote", h.QuoteFeeHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.FeeQuoteRequest{}
+	}))
+	g.GET("/:uuid", h.GetTransactionByIDHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
+	g.PUT("/:uuid/category", h.SetTransactionCategoryHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.SetTransactionCategoryRequest{}
+	}))
+	g.DELETE("/:uuid/category", h.ResetTransactionCategoryHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead), echokitMw.PathUuidV4Middleware("uuid"))
 }
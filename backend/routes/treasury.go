@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func RegisterTreasuryRoutes(e *echo.Group, h *handlers.Handler) {
+	g := e.Group("/admin/treasury")
+	g.Use(middleware.JWTMiddleware(h, auth.Treasury()))
+
+	g.GET("", h.GetTreasuryHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassRead))
+	g.POST("/mint", h.MintHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassTransfer), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.TreasuryOperationRequest{}
+	}))
+	g.POST("/burn", h.BurnHandler, middleware.RateLimitMiddleware(h, ratelimit.ClassTransfer), echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.TreasuryOperationRequest{}
+	}))
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	echokitMw "github.com/nrf24l01/go-web-utils/echokit/middleware"
+	"github.com/silaeder-labs/bank/backend/auth"
+	"github.com/silaeder-labs/bank/backend/handlers"
+	"github.com/silaeder-labs/bank/backend/middleware"
+	"github.com/silaeder-labs/bank/backend/ratelimit"
+	"github.com/silaeder-labs/bank/backend/schemas"
+)
+
+func RegisterUserRoutes(e *echo.Group, h *handlers.Handler) {
+	g := e.Group("/users")
+	g.Use(middleware.JWTMiddleware(h, auth.Authenticated()))
+	g.Use(middleware.RateLimitMiddleware(h, ratelimit.ClassRead))
+
+	g.GET("/search", h.SearchUsersHandler, echokitMw.QueryValidationMiddleware(func() interface{} {
+		return &schemas.SearchUsersRequest{}
+	}))
+	g.GET("/me", h.GetMeHandler)
+	g.PUT("/me/privacy", h.UpdatePrivacyHandler, echokitMw.BodyValidationMiddleware(func() interface{} {
+		return &schemas.UpdatePrivacyRequest{}
+	}))
+}
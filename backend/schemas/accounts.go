@@ -0,0 +1,43 @@
+package schemas
+
+import "github.com/google/uuid"
+
+type AccountKind string
+
+const (
+	AccountPersonal     AccountKind = "personal"
+	AccountOrganization AccountKind = "organization"
+	AccountSystem       AccountKind = "system"
+)
+
+type AccountRole string
+
+const (
+	RoleOwner   AccountRole = "owner"
+	RoleSpender AccountRole = "spender"
+	RoleViewer  AccountRole = "viewer"
+)
+
+type CreateAccountRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type SetAccountMemberRequest struct {
+	UserID uuid.UUID   `json:"user_id" validate:"required"`
+	Role   AccountRole `json:"role" validate:"required,oneof=owner spender viewer"`
+}
+
+type AccountFull struct {
+	ID        string      `json:"id"`
+	CreatedAt string      `json:"created_at"`
+	Kind      AccountKind `json:"kind"`
+	Name      string      `json:"name,omitempty"`
+	Role      AccountRole `json:"role,omitempty"`
+	Balance   int64       `json:"balance"`
+}
+
+type AccountMemberFull struct {
+	UserID  string      `json:"user_id"`
+	Role    AccountRole `json:"role"`
+	AddedAt string      `json:"added_at"`
+}
@@ -0,0 +1,30 @@
+package schemas
+
+import "time"
+
+type GetAnalyticsRequest struct {
+	From        *time.Time `query:"from"`
+	To          *time.Time `query:"to"`
+	Granularity string     `query:"granularity" validate:"omitempty,oneof=day week month"`
+	GroupBy     []string   `query:"group_by" validate:"omitempty,dive,oneof=category counterparty"`
+}
+
+// AnalyticsRowFull is one bucket, category and counterparty are set only when grouped by them.
+type AnalyticsRowFull struct {
+	Start            string `json:"start"`
+	Category         string `json:"category,omitempty"`
+	CounterpartyID   string `json:"counterparty_id,omitempty"`
+	CounterpartyName string `json:"counterparty_name,omitempty"`
+	Incoming         int64  `json:"incoming"`
+	Outgoing         int64  `json:"outgoing"`
+	IncomingCount    int64  `json:"incoming_count"`
+	OutgoingCount    int64  `json:"outgoing_count"`
+}
+
+type AnalyticsFull struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Granularity string             `json:"granularity"`
+	GroupBy     []string           `json:"group_by"`
+	Rows        []AnalyticsRowFull `json:"rows"`
+}
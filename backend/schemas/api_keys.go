@@ -0,0 +1,44 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	OwnerID   uuid.UUID  `json:"owner_id" validate:"required"`
+	Scopes    []string   `json:"scopes" validate:"dive,required,max=64"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateApiKeyRequest without expires_at keeps the expiry of the old key.
+type RotateApiKeyRequest struct {
+	GraceSeconds int64      `json:"grace_seconds" validate:"gte=0,lte=2592000"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+type ApiKeyFull struct {
+	ID          string   `json:"id"`
+	CreatedAt   string   `json:"created_at"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	OwnerID     string   `json:"owner_id"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	LastUsedAt  string   `json:"last_used_at,omitempty"`
+	RotatedFrom string   `json:"rotated_from,omitempty"`
+	Revoked     bool     `json:"revoked"`
+}
+
+// ApiKeyCreated carries the plaintext key, it is shown only once.
+type ApiKeyCreated struct {
+	ApiKeyFull
+	Key string `json:"key"`
+}
+
+type ListApiKeysRequest struct {
+	Page int `query:"page" validate:"gte=1"`
+	Size int `query:"size" validate:"gte=1,lte=100"`
+}
@@ -0,0 +1,65 @@
+package schemas
+
+type PendingTransferStatus string
+
+const (
+	TransferPending  PendingTransferStatus = "PENDING"
+	TransferExecuted PendingTransferStatus = "EXECUTED"
+	TransferRejected PendingTransferStatus = "REJECTED"
+	TransferExpired  PendingTransferStatus = "EXPIRED"
+)
+
+type ApprovalDecision string
+
+const (
+	DecisionApprove ApprovalDecision = "approve"
+	DecisionReject  ApprovalDecision = "reject"
+)
+
+type SetApprovalPolicyRequest struct {
+	Threshold         int64 `json:"threshold" validate:"gte=0"`
+	RequiredApprovals int   `json:"required_approvals" validate:"required,gte=1,lte=10"`
+	TTLSeconds        int   `json:"ttl_seconds,omitempty" validate:"omitempty,gte=60,lte=2592000"`
+}
+
+type ApprovalPolicyFull struct {
+	AccountID         string `json:"account_id"`
+	UpdatedAt         string `json:"updated_at"`
+	Threshold         int64  `json:"threshold"`
+	RequiredApprovals int    `json:"required_approvals"`
+	TTLSeconds        int    `json:"ttl_seconds"`
+}
+
+type TransferDecisionRequest struct {
+	Comment string `json:"comment,omitempty" validate:"max=200"`
+}
+
+type ListPendingTransfersRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=PENDING EXECUTED REJECTED EXPIRED"`
+	Page   int    `query:"page" validate:"gte=1"`
+	Size   int    `query:"size" validate:"gte=1,lte=100"`
+}
+
+type TransferApprovalFull struct {
+	UserID    string           `json:"user_id"`
+	Decision  ApprovalDecision `json:"decision"`
+	Comment   string           `json:"comment,omitempty"`
+	CreatedAt string           `json:"created_at"`
+}
+
+type PendingTransferFull struct {
+	ID                string                 `json:"id"`
+	CreatedAt         string                 `json:"created_at"`
+	ExpiresAt         string                 `json:"expires_at"`
+	Status            PendingTransferStatus  `json:"status"`
+	Source            string                 `json:"source"`
+	Target            string                 `json:"target"`
+	Initiator         string                 `json:"initiator"`
+	Amount            int64                  `json:"amount"`
+	Comment           string                 `json:"comment,omitempty"`
+	RequiredApprovals int                    `json:"required_approvals"`
+	Approvals         []TransferApprovalFull `json:"approvals"`
+	TransactionID     string                 `json:"transaction_id,omitempty"`
+	Metadata          map[string]any         `json:"metadata,omitempty"`
+	ExternalReference string                 `json:"external_reference,omitempty"`
+}
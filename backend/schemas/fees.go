@@ -0,0 +1,76 @@
+package schemas
+
+import "github.com/google/uuid"
+
+type FeeModel string
+
+const (
+	FeeFlat       FeeModel = "flat"
+	FeePercentage FeeModel = "percentage"
+	FeeTiered     FeeModel = "tiered"
+)
+
+// FeePayer is the side the fee is taken from: on top of the amount for the source, out of the received money for the target.
+type FeePayer string
+
+const (
+	FeePayerSource FeePayer = "source"
+	FeePayerTarget FeePayer = "target"
+)
+
+// FeeTier applies to amounts up to UpTo, the last tier may leave it empty.
+type FeeTier struct {
+	UpTo       *int64 `json:"up_to,omitempty" validate:"omitempty,gt=0"`
+	Flat       int64  `json:"flat" validate:"gte=0"`
+	PercentBps int64  `json:"percent_bps" validate:"gte=0,lte=10000"`
+}
+
+type CreateFeeRuleRequest struct {
+	Name            string          `json:"name" validate:"required,max=100"`
+	TransactionType TransactionType `json:"transaction_type" validate:"required,oneof=transfer payment refund"`
+	AccountKind     AccountKind     `json:"account_kind,omitempty" validate:"omitempty,oneof=personal organization"`
+	MinAmount       int64           `json:"min_amount" validate:"gte=0"`
+	MaxAmount       *int64          `json:"max_amount,omitempty" validate:"omitempty,gt=0"`
+	Model           FeeModel        `json:"model" validate:"required,oneof=flat percentage tiered"`
+	Flat            int64           `json:"flat" validate:"gte=0"`
+	PercentBps      int64           `json:"percent_bps" validate:"gte=0,lte=10000"`
+	Tiers           []FeeTier       `json:"tiers,omitempty" validate:"required_if=Model tiered,omitempty,max=20,dive"`
+	MinFee          int64           `json:"min_fee" validate:"gte=0"`
+	MaxFee          *int64          `json:"max_fee,omitempty" validate:"omitempty,gte=0"`
+	Payer           FeePayer        `json:"payer,omitempty" validate:"omitempty,oneof=source target"`
+	Priority        int             `json:"priority"`
+}
+
+type FeeRuleFull struct {
+	ID              string          `json:"id"`
+	CreatedAt       string          `json:"created_at"`
+	Name            string          `json:"name"`
+	TransactionType TransactionType `json:"transaction_type"`
+	AccountKind     AccountKind     `json:"account_kind,omitempty"`
+	MinAmount       int64           `json:"min_amount"`
+	MaxAmount       *int64          `json:"max_amount,omitempty"`
+	Model           FeeModel        `json:"model"`
+	Flat            int64           `json:"flat"`
+	PercentBps      int64           `json:"percent_bps"`
+	Tiers           []FeeTier       `json:"tiers,omitempty"`
+	MinFee          int64           `json:"min_fee"`
+	MaxFee          *int64          `json:"max_fee,omitempty"`
+	Payer           FeePayer        `json:"payer"`
+	Priority        int             `json:"priority"`
+}
+
+type FeeQuoteRequest struct {
+	Type     TransactionType `query:"type" validate:"omitempty,oneof=transfer payment refund"`
+	SourceID *uuid.UUID      `query:"source_id"`
+	TargetID uuid.UUID       `query:"target_id" validate:"required"`
+	Amount   int64           `query:"amount" validate:"required,gt=0"`
+}
+
+type FeeQuoteFull struct {
+	Amount    int64    `json:"amount"`
+	Fee       int64    `json:"fee"`
+	Payer     FeePayer `json:"payer,omitempty"`
+	Debit     int64    `json:"debit"`
+	Credit    int64    `json:"credit"`
+	FeeRuleID string   `json:"fee_rule_id,omitempty"`
+}
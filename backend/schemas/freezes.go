@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+type FreezeDirection string
+
+const (
+	FreezeOutgoing FreezeDirection = "outgoing"
+	FreezeIncoming FreezeDirection = "incoming"
+	FreezeBoth     FreezeDirection = "both"
+)
+
+type FreezeAccountRequest struct {
+	Direction FreezeDirection `json:"direction" validate:"required,oneof=outgoing incoming both"`
+	Reason    string          `json:"reason" validate:"required,max=200"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+type AccountFreezeFull struct {
+	ID        string          `json:"id"`
+	AccountID string          `json:"account_id"`
+	CreatedAt string          `json:"created_at"`
+	Direction FreezeDirection `json:"direction"`
+	Reason    string          `json:"reason"`
+	ExpiresAt string          `json:"expires_at,omitempty"`
+	CreatedBy string          `json:"created_by,omitempty"`
+	LiftedAt  string          `json:"lifted_at,omitempty"`
+	LiftedBy  string          `json:"lifted_by,omitempty"`
+}
@@ -0,0 +1,50 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MandatePeriod string
+
+const (
+	MandateDaily   MandatePeriod = "day"
+	MandateWeekly  MandatePeriod = "week"
+	MandateMonthly MandatePeriod = "month"
+)
+
+type CreateMandateRequest struct {
+	PayeeID     uuid.UUID     `json:"payee_id" validate:"required"`
+	Limit       int64         `json:"limit" validate:"required,gt=0"`
+	Period      MandatePeriod `json:"period" validate:"required,oneof=day week month"`
+	Description string        `json:"description,omitempty" validate:"max=120"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+}
+
+// ListMandatesRequest lists mandates the user gave as a payer or received as a payee.
+type ListMandatesRequest struct {
+	Role           PaymentRole `query:"role" validate:"omitempty,oneof=payer payee"`
+	IncludeRevoked bool        `query:"include_revoked"`
+}
+
+type MandateFull struct {
+	ID          string        `json:"id"`
+	CreatedAt   string        `json:"created_at"`
+	Payer       string        `json:"payer"`
+	Payee       string        `json:"payee"`
+	Limit       int64         `json:"limit"`
+	Period      MandatePeriod `json:"period"`
+	Used        int64         `json:"used"`
+	Description string        `json:"description,omitempty"`
+	ExpiresAt   string        `json:"expires_at,omitempty"`
+	RevokedAt   string        `json:"revoked_at,omitempty"`
+}
+
+type MandateDebitFull struct {
+	ID            string `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	PaymentID     string `json:"payment_id"`
+	TransactionID string `json:"transaction_id"`
+	Amount        int64  `json:"amount"`
+}
@@ -0,0 +1,32 @@
+package schemas
+
+import (
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Metadata goes into a GIN-indexed JSONB column, so its size is capped.
+const (
+	MetadataMaxKeys   = 20
+	MetadataMaxKeyLen = 64
+	MetadataMaxBytes  = 4096
+)
+
+// ValidateMetadata backs the "metadata" validation tag.
+func ValidateMetadata(fl validator.FieldLevel) bool {
+	m, ok := fl.Field().Interface().(map[string]any)
+	if !ok {
+		return false
+	}
+	if len(m) > MetadataMaxKeys {
+		return false
+	}
+	for k := range m {
+		if k == "" || len(k) > MetadataMaxKeyLen {
+			return false
+		}
+	}
+	encoded, err := json.Marshal(m)
+	return err == nil && len(encoded) <= MetadataMaxBytes
+}
@@ -0,0 +1,48 @@
+package schemas
+
+import "github.com/google/uuid"
+
+// CreateMoneyRequestRequest asks from_id to pay the current user.
+type CreateMoneyRequestRequest struct {
+	FromID      uuid.UUID `json:"from_id" validate:"required"`
+	Amount      int64     `json:"amount" validate:"required,gt=0"`
+	Description string    `json:"description,omitempty" validate:"max=100"`
+	Category    string    `json:"category,omitempty" validate:"max=64"`
+}
+
+type MoneyRequestBlockMode string
+
+const (
+	MoneyRequestMute  MoneyRequestBlockMode = "mute"
+	MoneyRequestBlock MoneyRequestBlockMode = "block"
+)
+
+type SetMoneyRequestBlockRequest struct {
+	Mode MoneyRequestBlockMode `json:"mode" validate:"required,oneof=mute block"`
+}
+
+type MoneyRequestBlockFull struct {
+	UserID    string                `json:"user_id"`
+	Mode      MoneyRequestBlockMode `json:"mode"`
+	CreatedAt string                `json:"created_at"`
+}
+
+type NotificationKind string
+
+const (
+	NotificationPaymentRequest  NotificationKind = "payment_request"
+	NotificationPaymentReminder NotificationKind = "payment_reminder"
+)
+
+type ListNotificationsRequest struct {
+	Unread bool `query:"unread"`
+	Limit  int  `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+type NotificationFull struct {
+	ID        string           `json:"id"`
+	CreatedAt string           `json:"created_at"`
+	Kind      NotificationKind `json:"kind"`
+	PaymentID string           `json:"payment_id,omitempty"`
+	ReadAt    string           `json:"read_at,omitempty"`
+}
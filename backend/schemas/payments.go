@@ -2,27 +2,113 @@ package schemas
 
 import "github.com/google/uuid"
 
+// CreatePaymentRequest without from_id creates an open request anyone can pay. Open requests may
+// leave the amount to the payer within min_amount/max_amount and limit the number of payments.
 type CreatePaymentRequest struct {
-	FromID      uuid.UUID `json:"from_id" validate:"required,uuid4"`
-	ToID        uuid.UUID `json:"to_id" validate:"required,uuid4"`
-	Amount      int64     `json:"amount" validate:"required,gt=0"`
-	Description string    `json:"description,omitempty" validate:"max=120"`
+	FromID      *uuid.UUID `json:"from_id,omitempty"`
+	ToID        uuid.UUID  `json:"to_id" validate:"required,uuid4"`
+	Amount      int64      `json:"amount,omitempty" validate:"required_with=FromID,omitempty,gt=0"`
+	Description string     `json:"description,omitempty" validate:"max=120"`
+	Category    string     `json:"category,omitempty" validate:"max=64"`
+
+	MaxUses   *int   `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	MinAmount *int64 `json:"min_amount,omitempty" validate:"omitempty,gt=0"`
+	MaxAmount *int64 `json:"max_amount,omitempty" validate:"omitempty,gt=0"`
+
+	Metadata map[string]any `json:"metadata,omitempty" validate:"omitempty,metadata"`
+}
+
+// PayPaymentRequest pays a part of the payment, without an amount the whole remaining sum is paid.
+// Open requests with a payer chosen amount require it.
+type PayPaymentRequest struct {
+	Amount int64 `json:"amount,omitempty" validate:"omitempty,gt=0"`
 }
 
 type PaymentStatus string
 
 const (
-	StatusPending   PaymentStatus = "UNPAID"
-	StatusCompleted PaymentStatus = "COMPLETED"
-	StatusCancelled PaymentStatus = "CANCELLED"
+	StatusPending       PaymentStatus = "UNPAID"
+	StatusPartiallyPaid PaymentStatus = "PARTIALLY_PAID"
+	StatusCompleted     PaymentStatus = "COMPLETED"
+	StatusCancelled     PaymentStatus = "CANCELLED"
+	StatusDeclined      PaymentStatus = "DECLINED"
 )
 
+type PaymentAction string
+
+const (
+	PaymentActionPay     PaymentAction = "pay"
+	PaymentActionDecline PaymentAction = "decline"
+	PaymentActionCancel  PaymentAction = "cancel"
+)
+
+// PaymentRole is what a user is to a payment, one user can hold several roles.
+type PaymentRole string
+
+const (
+	PaymentRolePayer   PaymentRole = "payer"
+	PaymentRolePayee   PaymentRole = "payee"
+	PaymentRoleCreator PaymentRole = "creator"
+)
+
+type DeclinePaymentRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
 type PaymentFull struct {
-	ID          string        `json:"id"`
-	CreateAt    string        `json:"created_at"`
-	From        string        `json:"from" validate:"required,uuid4"`
-	To          string        `json:"to" validate:"required,uuid4"`
-	Amount      int64         `json:"amount"`
-	Status      PaymentStatus `json:"status"`
-	Description string        `json:"description,omitempty"`
+	ID           string        `json:"id"`
+	CreateAt     string        `json:"created_at"`
+	From         string        `json:"from,omitempty"`
+	To           string        `json:"to" validate:"required,uuid4"`
+	Amount       int64         `json:"amount"`
+	AmountPaid   int64         `json:"amount_paid"`
+	Status       PaymentStatus `json:"status"`
+	StatusReason string        `json:"status_reason,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Category     string        `json:"category,omitempty"`
+
+	MaxUses   *int   `json:"max_uses,omitempty"`
+	Uses      int    `json:"uses"`
+	MinAmount *int64 `json:"min_amount,omitempty"`
+	MaxAmount *int64 `json:"max_amount,omitempty"`
+	MandateID string `json:"mandate_id,omitempty"`
+	SplitID   string `json:"split_id,omitempty"`
+
+	Metadata     map[string]any           `json:"metadata,omitempty"`
+	Installments []PaymentInstallmentFull `json:"installments,omitempty"`
+}
+
+type PaymentInstallmentFull struct {
+	ID            string `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	TransactionID string `json:"transaction_id"`
+	Payer         string `json:"payer"`
+	Amount        int64  `json:"amount"`
+}
+
+type PaymentLinkFull struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type PaymentQRRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=png svg"`
+	Size   int    `query:"size" validate:"omitempty,gte=64,lte=1024"`
+}
+
+// PaymentPreviewRequest carries the expiry and signature from the payment link.
+type PaymentPreviewRequest struct {
+	Expires   int64  `query:"exp" validate:"required"`
+	Signature string `query:"sig" validate:"required"`
+}
+
+// PaymentPreviewFull is public, so it leaves out the payer, the description and the metadata.
+type PaymentPreviewFull struct {
+	ID         string        `json:"id"`
+	Amount     int64         `json:"amount"`
+	AmountPaid int64         `json:"amount_paid"`
+	Status     PaymentStatus `json:"status"`
+	PayeeName  string        `json:"payee_name,omitempty"`
+	MinAmount  *int64        `json:"min_amount,omitempty"`
+	MaxAmount  *int64        `json:"max_amount,omitempty"`
 }
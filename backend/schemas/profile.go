@@ -1,7 +1,60 @@
 package schemas
 
+import "time"
+
+type GetProfileRequest struct {
+	Periods []string `query:"periods" validate:"omitempty,max=5,unique,dive,oneof=day week month year all"`
+}
+
+type PeriodTotals struct {
+	Period            string `json:"period"`
+	Since             string `json:"since,omitempty"`
+	Incoming          int64  `json:"incoming"`
+	Outgoing          int64  `json:"outgoing"`
+	TotalTransactions int64  `json:"total_transactions"`
+}
+
 type BalanceFull struct {
 	Id                string `json:"id"`
 	Balance           int64  `json:"balance"`
 	TotalTransactions int64  `json:"total_transactions"`
+
+	Unlimited             bool           `json:"unlimited"`
+	LastActivityAt        string         `json:"last_activity_at,omitempty"`
+	PendingPayments       int64          `json:"pending_payments"`
+	PendingPaymentsAmount int64          `json:"pending_payments_amount"`
+	Periods               []PeriodTotals `json:"periods"`
+
+	Freeze *AccountFreezeFull `json:"freeze,omitempty"`
+}
+
+type GetBalanceAtRequest struct {
+	At *time.Time `query:"at"`
+}
+
+type BalanceAtFull struct {
+	At      string `json:"at"`
+	Balance int64  `json:"balance"`
+}
+
+type GetBalanceHistoryRequest struct {
+	From        *time.Time `query:"from"`
+	To          *time.Time `query:"to"`
+	Granularity string     `query:"granularity" validate:"omitempty,oneof=day week month"`
+}
+
+type BalancePointFull struct {
+	Start    string `json:"start"`
+	At       string `json:"at"`
+	Balance  int64  `json:"balance"`
+	Incoming int64  `json:"incoming"`
+	Outgoing int64  `json:"outgoing"`
+}
+
+type BalanceHistoryFull struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Granularity string             `json:"granularity"`
+	Opening     int64              `json:"opening"`
+	Points      []BalancePointFull `json:"points"`
 }
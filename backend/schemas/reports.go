@@ -0,0 +1,64 @@
+package schemas
+
+import "time"
+
+type ReportFormat string
+
+const (
+	ReportJSON ReportFormat = "json"
+	ReportCSV  ReportFormat = "csv"
+)
+
+type MoneySupplyRequest struct {
+	Format ReportFormat `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type ReportRangeRequest struct {
+	From   *time.Time   `query:"from"`
+	To     *time.Time   `query:"to"`
+	Format ReportFormat `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type TopAccountsRequest struct {
+	By     string       `query:"by" validate:"omitempty,oneof=balance volume"`
+	Limit  int          `query:"limit" validate:"omitempty,gte=1,lte=100"`
+	From   *time.Time   `query:"from"`
+	To     *time.Time   `query:"to"`
+	Format ReportFormat `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type ActiveUsersRequest struct {
+	From        *time.Time   `query:"from"`
+	To          *time.Time   `query:"to"`
+	Granularity string       `query:"granularity" validate:"omitempty,oneof=day week month"`
+	Format      ReportFormat `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// MoneySupplyFull compares money held by accounts (circulation) with what the treasury issued.
+type MoneySupplyFull struct {
+	Circulation   int64 `json:"circulation"`
+	Issued        int64 `json:"issued"`
+	IssuedGross   int64 `json:"issued_gross"`
+	ReturnedGross int64 `json:"returned_gross"`
+	Accounts      int64 `json:"accounts"`
+	Issuers       int64 `json:"issuers"`
+}
+
+type DailyVolumeFull struct {
+	Day          string `json:"day"`
+	Volume       int64  `json:"volume"`
+	Transactions int64  `json:"transactions"`
+}
+
+type TopAccountFull struct {
+	AccountID    string `json:"account_id"`
+	Name         string `json:"name,omitempty"`
+	Balance      int64  `json:"balance"`
+	Volume       int64  `json:"volume"`
+	Transactions int64  `json:"transactions"`
+}
+
+type ActiveUsersFull struct {
+	Start       string `json:"start"`
+	ActiveUsers int64  `json:"active_users"`
+}
@@ -0,0 +1,43 @@
+package schemas
+
+import "github.com/google/uuid"
+
+type SplitMode string
+
+const (
+	SplitEqual      SplitMode = "equal"
+	SplitPercentage SplitMode = "percentage"
+	SplitFixed      SplitMode = "fixed"
+)
+
+// SplitParticipant is one payer of a split. Share is left out for equal splits, it is in basis
+// points (10000 = 100%) for percentage splits and in cents for fixed ones.
+type SplitParticipant struct {
+	PayerID uuid.UUID `json:"payer_id" validate:"required"`
+	Share   int64     `json:"share,omitempty" validate:"omitempty,gt=0"`
+}
+
+// CreateSplitPaymentRequest shares amount between the participants, fixed splits may leave
+// the amount out and take the sum of the shares.
+type CreateSplitPaymentRequest struct {
+	ToID         uuid.UUID          `json:"to_id" validate:"required"`
+	Amount       int64              `json:"amount,omitempty" validate:"omitempty,gt=0"`
+	Mode         SplitMode          `json:"mode" validate:"required,oneof=equal percentage fixed"`
+	Participants []SplitParticipant `json:"participants" validate:"required,min=1,max=50,dive"`
+	Description  string             `json:"description,omitempty" validate:"max=100"`
+	Category     string             `json:"category,omitempty" validate:"max=64"`
+}
+
+type SplitPaymentFull struct {
+	ID          string        `json:"id"`
+	CreatedAt   string        `json:"created_at"`
+	Creator     string        `json:"creator"`
+	To          string        `json:"to"`
+	Amount      int64         `json:"amount"`
+	AmountPaid  int64         `json:"amount_paid"`
+	Mode        SplitMode     `json:"mode"`
+	Status      PaymentStatus `json:"status"`
+	Description string        `json:"description,omitempty"`
+	Category    string        `json:"category,omitempty"`
+	Payments    []PaymentFull `json:"payments"`
+}
@@ -2,22 +2,56 @@ package schemas
 
 import "github.com/google/uuid"
 
+type TransactionType string
+
+const (
+	TransactionTransfer   TransactionType = "transfer"
+	TransactionPayment    TransactionType = "payment"
+	TransactionRefund     TransactionType = "refund"
+	TransactionMint       TransactionType = "mint"
+	TransactionBurn       TransactionType = "burn"
+	TransactionFee        TransactionType = "fee"
+	TransactionAdjustment TransactionType = "adjustment"
+)
+
 type CreateTransactionRequest struct {
-	TargetID uuid.UUID `json:"target_id" validate:"required,uuid4"`
-	Amount   int64     `json:"amount" validate:"required,gt=0"`
-	Comment  string    `json:"comment,omitempty" validate:"max=100"`
+	TargetID        uuid.UUID  `json:"target_id" validate:"required_without=TargetUsername,omitempty,uuid4"`
+	TargetUsername  string     `json:"target_username,omitempty" validate:"max=255"`
+	SourceAccountID *uuid.UUID `json:"source_account_id,omitempty"`
+	Amount          int64      `json:"amount" validate:"required,gt=0"`
+	Comment         string     `json:"comment,omitempty" validate:"max=100"`
+
+	Metadata          map[string]any `json:"metadata,omitempty" validate:"omitempty,metadata"`
+	ExternalReference string         `json:"external_reference,omitempty" validate:"max=128"`
 }
 
 type TransactionFull struct {
-	ID        string `json:"transaction_id"`
-	CreatedAt string `json:"created_at"`
-	Amount    int64  `json:"amount"`
-	Source    string `json:"source" validate:"required,uuid4"`
-	Target    string `json:"target" validate:"required,uuid4"`
-	Comment   string `json:"comment,omitempty"`
+	ID         string          `json:"transaction_id"`
+	CreatedAt  string          `json:"created_at"`
+	Type       TransactionType `json:"type"`
+	Amount     int64           `json:"amount"`
+	Source     string          `json:"source" validate:"required,uuid4"`
+	SourceName string          `json:"source_name,omitempty"`
+	Target     string          `json:"target" validate:"required,uuid4"`
+	TargetName string          `json:"target_name,omitempty"`
+	Comment    string          `json:"comment,omitempty"`
+	Category   string          `json:"category,omitempty"`
+	Fee        int64           `json:"fee,omitempty"`
+	ParentID   string          `json:"parent_id,omitempty"`
+
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	ExternalReference string         `json:"external_reference,omitempty"`
+}
+
+type SetTransactionCategoryRequest struct {
+	Category string `json:"category" validate:"required,max=64"`
 }
 
 type GetTransactionsRequest struct {
 	Page int `query:"page" validate:"gte=1"`
 	Size int `query:"size" validate:"gte=1,lte=100"`
+
+	Type              TransactionType `query:"type" validate:"omitempty,oneof=transfer payment refund mint burn fee adjustment"`
+	Metadata          string          `query:"metadata" validate:"omitempty,json"`
+	ExternalReference string          `query:"external_reference" validate:"max=128"`
 }
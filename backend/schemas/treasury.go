@@ -0,0 +1,16 @@
+package schemas
+
+import "github.com/google/uuid"
+
+type TreasuryOperationRequest struct {
+	AccountID uuid.UUID `json:"account_id" validate:"required,uuid4"`
+	Amount    int64     `json:"amount" validate:"required,gt=0"`
+	Comment   string    `json:"comment,omitempty" validate:"max=100"`
+}
+
+type TreasuryFull struct {
+	AccountID string `json:"account_id"`
+	Supply    int64  `json:"supply"`
+	Minted    int64  `json:"minted"`
+	Burned    int64  `json:"burned"`
+}
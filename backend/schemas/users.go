@@ -0,0 +1,25 @@
+package schemas
+
+type SearchUsersRequest struct {
+	Query string `query:"q" validate:"required,min=2,max=64"`
+	Size  int    `query:"size" validate:"omitempty,gte=1,lte=20"`
+}
+
+type UpdatePrivacyRequest struct {
+	Discoverable *bool `json:"discoverable" validate:"required"`
+}
+
+// UserPublic is what other users may see, email is never exposed.
+type UserPublic struct {
+	ID       string `json:"id"`
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+type UserFull struct {
+	ID           string `json:"id"`
+	Username     string `json:"username,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Discoverable bool   `json:"discoverable"`
+}